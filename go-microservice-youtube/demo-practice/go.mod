@@ -2,4 +2,18 @@ module microservices
 
 go 1.22.6
 
-require github.com/nicholasjackson/env v0.6.1 // indirect
+require github.com/nicholasjackson/env v0.6.1
+
+require shared v0.0.0
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.14.0 // indirect
+	golang.org/x/sys v0.11.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
+	google.golang.org/grpc v1.59.0 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+)
+
+replace shared => ../../go-microservices/go-building-microservices/shared
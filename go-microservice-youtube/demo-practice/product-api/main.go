@@ -5,10 +5,10 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/signal"
 	"time"
 
 	"microservices/product-api/handler"
+	"shared/lifecycle"
 
 	"github.com/nicholasjackson/env"
 )
@@ -39,28 +39,14 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
-	// start the server
-	go func() {
-		l.Println("Starting server on port 9090")
-
-		err := s.ListenAndServe()
-		if err != nil {
-			l.Printf("Error starting server: %s\n", err)
-			os.Exit(1)
-		}
-	}()
-
-	// trap sigterm or interupt and gracefully shutdown the server
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	signal.Notify(c, os.Kill)
-
-	// Block until a singal is received
-	sig := <-c
-	log.Println("Got signal:", sig)
-
-	// gracefully shutdown the server, waiting max 30 secons for current operations to complete
-	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
-	s.Shutdown(ctx)
-
+	// start the server and block until SIGINT or SIGTERM, then gracefully
+	// shut it down, waiting max 30 seconds for current operations to
+	// complete
+	sup := lifecycle.New()
+	sup.ShutdownTimeout = 30 * time.Second
+	sup.Add("products-api", &s)
+	if err := sup.Run(context.Background()); err != nil {
+		l.Printf("Error running server: %s\n", err)
+		os.Exit(1)
+	}
 }
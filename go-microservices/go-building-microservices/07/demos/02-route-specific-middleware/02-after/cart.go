@@ -3,136 +3,395 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"log"
-	"log/slog"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
+
+	"demo/client"
+	"demo/health"
+	"demo/idempotency"
+	"demo/registry"
+	"demo/render"
+	"shared/metrics"
+	"shared/middleware"
+	"shared/problem"
+	"shared/ratelimit"
+	"shared/trace"
 )
 
+type CartItem struct {
+	ProductID int `json:"productId" xml:"productId"`
+	Quantity  int `json:"quantity" xml:"quantity"`
+}
+
 type Cart struct {
-	ID         int   `json:"id,omitempty"`
-	CustomerID int   `json:"customerId,omitempty"`
-	ProductIDs []int `json:"productIds,omitempty"`
+	ID         int        `json:"id,omitempty" xml:"id,omitempty"`
+	CustomerID int        `json:"customerId,omitempty" xml:"customerId,omitempty"`
+	Items      []CartItem `json:"items,omitempty" xml:"item,omitempty"`
+}
+
+var errInvalidItem = errors.New("item must have a productId and a positive quantity")
+var errItemNotFound = errors.New("item not found in cart")
+
+// addItem adds quantity units of the product to the cart, merging with an
+// existing line for the same product.
+func (c *Cart) addItem(item CartItem) error {
+	if item.ProductID <= 0 || item.Quantity <= 0 {
+		return errInvalidItem
+	}
+	for i := range c.Items {
+		if c.Items[i].ProductID == item.ProductID {
+			c.Items[i].Quantity += item.Quantity
+			return nil
+		}
+	}
+	c.Items = append(c.Items, item)
+	return nil
+}
+
+func (c *Cart) removeItem(productID int) error {
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			return nil
+		}
+	}
+	return errItemNotFound
 }
 
-var nextID int = 1
-var carts = make([]Cart, 0)
+// setItems replaces the cart contents, merging duplicate product IDs.
+func (c *Cart) setItems(items []CartItem) error {
+	c.Items = nil
+	for _, item := range items {
+		if err := c.addItem(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-var cartMux = http.NewServeMux()
+// cartPatch is the body of a PATCH request. Only the fields that are
+// present are applied.
+type cartPatch struct {
+	CustomerID *int        `json:"customerId"`
+	Items      *[]CartItem `json:"items"`
+}
 
-func createShoppingCartService() *http.Server {
+// cartsPolicy limits how often a client may list and create carts.
+var cartsPolicy = ratelimit.Policy{Name: "carts", Limit: 30, Window: time.Minute}
 
-	cartMux.Handle("/carts", &validationMiddleware{next: http.HandlerFunc(cartsHandler)})
+// idempotencyTTL is how long the response to a POST /carts with an
+// Idempotency-Key is replayed for.
+const idempotencyTTL = 24 * time.Hour
+
+func createShoppingCartService(addr string, store CartStore, resolver registry.Resolver, checks *health.Checker, tracer *trace.Tracer, limiter *ratelimit.Limiter, internalToken string) *http.Server {
+	cfg := client.DefaultConfig()
+	cfg.Transport = middleware.RequestIDTransport(trace.Transport(tracer, nil))
+	cfg.Header = http.Header{internalTokenHeader: {internalToken}}
+	up := &upstreams{client: client.New(resolver, cfg)}
+	checks.AddReadiness("customer-service", up.ping(customerServiceName))
+	checks.AddReadiness("product-service", up.ping(productServiceName))
+
+	reg := newMetrics()
+	router := middleware.NewRouter(trace.Middleware(tracer), middleware.Logging(loggingConfig()), metrics.Middleware(reg))
+	router.Handle("/carts", cartsHandler(store),
+		limiter.Middleware(cartsPolicy),
+		idempotency.Middleware(idempotency.NewMemoryStore(idempotencyTTL), ratelimit.ByIP),
+		validation(up, true),
+	)
+	router.Handle("/carts/", cartRoutes(store, up))
+	router.Handle("/debug/upstreams", up.client.Handler())
+	router.Handle("/metrics", reg.Handler())
+	router.Handle("/healthz", checks.LivenessHandler())
+	router.Handle("/readyz", checks.ReadinessHandler())
+	router.Handle("/", problem.NotFoundHandler())
 
 	s := http.Server{
-		Addr:    ":5000",
-		Handler: &loggingMiddleware{next: cartMux},
+		Addr:    addr,
+		Handler: router,
 	}
 
 	return &s
 
 }
 
-type validationMiddleware struct {
-	next http.Handler
+// maxBodySize bounds the cart and item bodies the validation middleware
+// reads into memory.
+const maxBodySize = 1 << 20
+
+// validationRequest covers both request shapes the middleware sees: a
+// cart with a customer and items, and a single item.
+type validationRequest struct {
+	CustomerID int        `json:"customerId"`
+	Items      []CartItem `json:"items"`
+	ProductID  int        `json:"productId"`
 }
 
-func (vm validationMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if vm.next == nil {
-		log.Panic("No next handler defined for validationMiddleware")
-	}
+// validation checks that the customer and products named in a cart or
+// cart item body exist before the request reaches the handler.
+// requireCustomer is set on routes whose POST and PUT bodies describe a
+// whole cart, so a missing customer ID is an error rather than
+// "unchanged".
+func validation(up *upstreams, requireCustomer bool) middleware.Middleware {
+	return middleware.New("validation", func(next http.Handler) http.Handler {
+		return problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch {
+				next.ServeHTTP(w, r)
+				return nil
+			}
 
-	if r.Method != http.MethodPost {
-		vm.next.ServeHTTP(w, r)
-		return
-	}
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return problem.New(http.StatusRequestEntityTooLarge, "request body must be at most %d bytes", tooLarge.Limit)
+			}
+			if err != nil {
+				return err
+			}
 
-	data, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
+			var c validationRequest
+			err = json.Unmarshal(data, &c)
+			if err != nil {
+				return problem.BadRequest("invalid request body: %v", err)
+			}
 
-	var c Cart
-	err = json.Unmarshal(data, &c)
-	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
+			var invalidCustomerID int
+			var invalidProductIDs []int
 
-	res, err := http.Head(fmt.Sprintf("http://localhost:3000/customers/%v", c.CustomerID))
-	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	if res.StatusCode == http.StatusNotFound {
-		log.Print("Invalid customer ID")
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
+			// A PATCH that leaves the customer alone has nothing to check.
+			if c.CustomerID != 0 || (requireCustomer && r.Method != http.MethodPatch) {
+				// Zero is what an omitted customerId decodes to, so it
+				// must be caught here rather than reported as unknown.
+				if c.CustomerID <= 0 {
+					return problem.BadRequest("customerId must be a positive integer")
+				}
+				err := up.checkCustomer(r.Context(), c.CustomerID)
+				if errors.Is(err, ErrCustomerNotFound) {
+					log.Print("Invalid customer ID")
+					invalidCustomerID = c.CustomerID
+				} else if err != nil {
+					return lookupError(w, err)
+				}
+			}
 
-	b := bytes.NewBuffer(data)
-	r.Body = io.NopCloser(b)
-	vm.next.ServeHTTP(w, r)
-}
+			ids := make([]int, 0, len(c.Items)+1)
+			for _, item := range c.Items {
+				ids = append(ids, item.ProductID)
+			}
+			if c.ProductID != 0 {
+				ids = append(ids, c.ProductID)
+			}
+			if len(ids) > 0 {
+				_, unknown, err := up.lookupProducts(r.Context(), ids)
+				if err != nil {
+					return lookupError(w, err)
+				}
+				if len(unknown) > 0 {
+					log.Printf("Invalid product IDs: %v", unknown)
+					invalidProductIDs = unknown
+				}
+			}
+
+			if invalidCustomerID != 0 || len(invalidProductIDs) > 0 {
+				return unknownReferences("cart references unknown customers or products", invalidCustomerID, invalidProductIDs)
+			}
 
-type loggingMiddleware struct {
-	next http.Handler
+			b := bytes.NewBuffer(data)
+			r.Body = io.NopCloser(b)
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	})
 }
 
-func (lm loggingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if lm.next == nil {
-		lm.next = cartMux
+func cartsHandler(store CartStore) problem.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		switch r.Method {
+		case http.MethodGet:
+			carts, err := store.List()
+			if err != nil {
+				return err
+			}
+			render.Respond(w, r, http.StatusOK, carts)
+		case http.MethodPost:
+			if err := render.Acceptable(r, Cart{}); err != nil {
+				return err
+			}
+			var c Cart
+			dec := json.NewDecoder(r.Body)
+			err := dec.Decode(&c)
+			if err != nil {
+				return problem.BadRequest("invalid cart: %v", err)
+			}
+			if err := c.setItems(c.Items); err != nil {
+				return storeError(err)
+			}
+			c, err = store.Create(c)
+			if err != nil {
+				return err
+			}
+			render.Respond(w, r, http.StatusCreated, c)
+		default:
+			return problem.MethodNotAllowed(r)
+		}
+		return nil
 	}
+}
 
-	slog.Info(fmt.Sprintf("Received %v request on route: %v", r.Method, r.URL.Path))
-	now := time.Now()
+var (
+	cartPattern      = regexp.MustCompile(`^\/carts\/(\d+?)$`)
+	cartItemsPattern = regexp.MustCompile(`^\/carts\/(\d+?)\/items$`)
+	cartItemPattern  = regexp.MustCompile(`^\/carts\/(\d+?)\/items\/(\d+?)$`)
+)
 
-	lm.next.ServeHTTP(w, r)
+// cartRoutes dispatches everything below /carts/ to the handler for a
+// single cart, its items or its priced summary.
+func cartRoutes(store CartStore, up *upstreams) http.Handler {
+	cart := middleware.Chain(cartHandler(store), validation(up, true))
+	items := middleware.Chain(cartItemsHandler(store), validation(up, false))
+	summary := cartSummaryHandler(store, up)
 
-	slog.Info(fmt.Sprintf("Response generated for %v request on route: %v. Duration: %v", r.Method, r.URL.Path, time.Since(now)))
+	return problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		switch {
+		case cartPattern.MatchString(r.URL.Path):
+			cart.ServeHTTP(w, r)
+		case cartItemsPattern.MatchString(r.URL.Path), cartItemPattern.MatchString(r.URL.Path):
+			items.ServeHTTP(w, r)
+		case cartSummaryPattern.MatchString(r.URL.Path):
+			summary.ServeHTTP(w, r)
+		default:
+			return problem.NotFound(r)
+		}
+		return nil
+	})
+}
 
+func cartHandler(store CartStore) problem.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		matches := cartPattern.FindStringSubmatch(r.URL.Path)
+		id, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return problem.BadRequest("invalid cart ID %q", matches[1])
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			c, err := store.Get(id)
+			if err != nil {
+				return storeError(err)
+			}
+			render.Respond(w, r, http.StatusOK, c)
+		case http.MethodPut:
+			if err := render.Acceptable(r, Cart{}); err != nil {
+				return err
+			}
+			var body Cart
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				return problem.BadRequest("invalid cart: %v", err)
+			}
+			c, err := store.Update(id, func(c *Cart) error {
+				c.CustomerID = body.CustomerID
+				return c.setItems(body.Items)
+			})
+			if err != nil {
+				return storeError(err)
+			}
+			render.Respond(w, r, http.StatusOK, c)
+		case http.MethodPatch:
+			if err := render.Acceptable(r, Cart{}); err != nil {
+				return err
+			}
+			var patch cartPatch
+			if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+				return problem.BadRequest("invalid patch: %v", err)
+			}
+			c, err := store.Update(id, func(c *Cart) error {
+				if patch.CustomerID != nil {
+					c.CustomerID = *patch.CustomerID
+				}
+				if patch.Items != nil {
+					return c.setItems(*patch.Items)
+				}
+				return nil
+			})
+			if err != nil {
+				return storeError(err)
+			}
+			render.Respond(w, r, http.StatusOK, c)
+		case http.MethodDelete:
+			if err := store.Delete(id); err != nil {
+				return storeError(err)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			return problem.MethodNotAllowed(r)
+		}
+		return nil
+	}
 }
 
-func cartsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		data, err := json.Marshal(carts)
+func cartItemsHandler(store CartStore) problem.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if matches := cartItemsPattern.FindStringSubmatch(r.URL.Path); len(matches) > 0 {
+			if r.Method != http.MethodPost {
+				return problem.MethodNotAllowed(r)
+			}
+			id, err := strconv.Atoi(matches[1])
+			if err != nil {
+				return problem.BadRequest("invalid cart ID %q", matches[1])
+			}
+			if err := render.Acceptable(r, Cart{}); err != nil {
+				return err
+			}
+			var item CartItem
+			if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+				return problem.BadRequest("invalid item: %v", err)
+			}
+			c, err := store.Update(id, func(c *Cart) error {
+				return c.addItem(item)
+			})
+			if err != nil {
+				return storeError(err)
+			}
+			render.Respond(w, r, http.StatusCreated, c)
+			return nil
+		}
+
+		matches := cartItemPattern.FindStringSubmatch(r.URL.Path)
+		if r.Method != http.MethodDelete {
+			return problem.MethodNotAllowed(r)
+		}
+		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			log.Print(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return problem.BadRequest("invalid cart ID %q", matches[1])
 		}
-		w.Header().Add("Content-Type", "application/json")
-		w.Write(data)
-	case http.MethodPost:
-		var c Cart
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&c)
+		productID, err := strconv.Atoi(matches[2])
 		if err != nil {
-			log.Print(err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			return problem.BadRequest("invalid product ID %q", matches[2])
 		}
-		c.ID = nextID
-		nextID++
-		carts = append(carts, c)
-		w.WriteHeader(http.StatusCreated)
-		data, err := json.Marshal(c)
+		_, err = store.Update(id, func(c *Cart) error {
+			return c.removeItem(productID)
+		})
 		if err != nil {
-			log.Print(err)
-			fmt.Fprint(w, "Failed to return created cart data")
-			return
+			return storeError(err)
 		}
-		w.Write(data)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
 
-	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
+// storeError maps errors from the store and from cart mutations to the
+// problem reported to the client.
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, ErrCartNotFound), errors.Is(err, errItemNotFound):
+		return problem.Wrap(http.StatusNotFound, err)
+	case errors.Is(err, errInvalidItem):
+		return problem.Wrap(http.StatusBadRequest, err)
 	}
+	return err
 }
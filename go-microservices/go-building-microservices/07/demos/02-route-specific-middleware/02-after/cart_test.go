@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCartWritesNegotiateFirst(t *testing.T) {
+	store := newMemoryCartStore()
+	store.Create(Cart{CustomerID: 1})
+
+	// The handlers are called directly, without the validation middleware
+	// and its upstream lookups.
+	tests := []struct {
+		handler      http.Handler
+		method, path string
+	}{
+		{cartsHandler(store), http.MethodPost, "/carts"},
+		{cartHandler(store), http.MethodPut, "/carts/1"},
+		{cartHandler(store), http.MethodPatch, "/carts/1"},
+		{cartItemsHandler(store), http.MethodPost, "/carts/1/items"},
+	}
+	for _, tt := range tests {
+		body := `{"customerId":2,"items":[{"productId":1,"quantity":1}],"productId":1,"quantity":1}`
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+		r.Header.Set("Accept", "image/png")
+		w := httptest.NewRecorder()
+		tt.handler.ServeHTTP(w, r)
+		if w.Code != http.StatusNotAcceptable {
+			t.Errorf("%v %v: status %d, want %d", tt.method, tt.path, w.Code, http.StatusNotAcceptable)
+		}
+	}
+
+	carts, _ := store.List()
+	if len(carts) != 1 || carts[0].CustomerID != 1 || len(carts[0].Items) != 0 {
+		t.Errorf("store changed by unacceptable requests: %v", carts)
+	}
+}
+
+func TestValidationRejectsLargeBodies(t *testing.T) {
+	// The body is refused before any upstream is asked, so none is set up.
+	h := validation(&upstreams{}, true).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("oversized body reached the handler")
+	}))
+
+	body := `{"customerId":1,"items":[` + strings.Repeat(`{"productId":1,"quantity":1},`, maxBodySize/20) + `]}`
+	r := httptest.NewRequest(http.MethodPost, "/carts", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
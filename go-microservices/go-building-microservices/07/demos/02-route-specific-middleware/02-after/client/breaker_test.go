@@ -0,0 +1,78 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	now := time.Now()
+	b := &breaker{threshold: 2, cooldown: 10 * time.Second, now: func() time.Time { return now }}
+
+	fail := func() {
+		t.Helper()
+		if err := b.allow(); err != nil {
+			t.Fatalf("call refused in state %v: %v", b.current(), err)
+		}
+		b.record(false)
+	}
+
+	fail()
+	if b.current() != Closed {
+		t.Fatalf("state after 1 failure = %v, want closed", b.current())
+	}
+	fail()
+	if b.current() != Open {
+		t.Fatalf("state after 2 failures = %v, want open", b.current())
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open breaker allowed a call: %v", err)
+	}
+
+	// After the cooldown a single probe goes through, and a failed probe
+	// opens the circuit again at once.
+	now = now.Add(10 * time.Second)
+	if b.current() != HalfOpen {
+		t.Fatalf("state after cooldown = %v, want half-open", b.current())
+	}
+	fail()
+	if b.current() != Open {
+		t.Fatalf("state after a failed probe = %v, want open", b.current())
+	}
+
+	now = now.Add(10 * time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("a second call got through while the probe was in flight")
+	}
+	b.record(true)
+	if b.current() != Closed {
+		t.Fatalf("state after a successful probe = %v, want closed", b.current())
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("closed breaker refused a call: %v", err)
+	}
+	b.record(true)
+}
+
+func TestBreakerAbandonedProbe(t *testing.T) {
+	now := time.Now()
+	b := &breaker{threshold: 1, cooldown: time.Second, now: func() time.Time { return now }}
+	b.allow()
+	b.record(false)
+
+	now = now.Add(time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.abandon()
+	if b.current() != HalfOpen {
+		t.Errorf("state after an abandoned probe = %v, want half-open", b.current())
+	}
+	if err := b.allow(); err != nil {
+		t.Errorf("next probe refused after one was abandoned: %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+// Package client is the HTTP client the services use to call each other.
+// It resolves service names through a registry, bounds every attempt with
+// a timeout, retries idempotent requests with jittered exponential backoff
+// and stops calling an upstream that keeps failing.
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"demo/registry"
+	"demo/render"
+)
+
+type Config struct {
+	// Timeout bounds a single attempt. A shorter deadline on the caller's
+	// context still wins.
+	Timeout time.Duration
+	// MaxRetries is how many times an idempotent request is repeated after
+	// the first attempt fails.
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// FailureThreshold consecutive failures open the circuit for an
+	// upstream; after OpenTimeout a single probe is let through.
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	// Transport is used to send requests. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// Header is added to every request, such as a token the upstream
+	// recognises.
+	Header http.Header
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          2 * time.Second,
+		MaxRetries:       2,
+		BaseBackoff:      50 * time.Millisecond,
+		MaxBackoff:       1 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      10 * time.Second,
+	}
+}
+
+type Client struct {
+	resolver registry.Resolver
+	http     *http.Client
+	cfg      Config
+
+	mu        sync.Mutex
+	upstreams map[string]*upstream
+}
+
+// upstream holds the breaker and counters for one service name.
+type upstream struct {
+	breaker  breaker
+	requests atomic.Int64
+	failures atomic.Int64
+	retries  atomic.Int64
+	rejected atomic.Int64
+	latency  atomic.Int64 // total nanoseconds across all attempts
+}
+
+// Stats is a snapshot of the calls made to one upstream.
+type Stats struct {
+	Requests     int64   `json:"requests"`
+	Failures     int64   `json:"failures"`
+	Retries      int64   `json:"retries"`
+	Rejected     int64   `json:"rejected"`
+	AvgLatencyMS float64 `json:"avgLatencyMs"`
+	State        State   `json:"state"`
+}
+
+func New(resolver registry.Resolver, cfg Config) *Client {
+	transport := cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Client{
+		resolver:  resolver,
+		http:      &http.Client{Transport: transport},
+		cfg:       cfg,
+		upstreams: make(map[string]*upstream),
+	}
+}
+
+func (c *Client) upstream(service string) *upstream {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, ok := c.upstreams[service]
+	if !ok {
+		u = &upstream{breaker: breaker{
+			threshold: c.cfg.FailureThreshold,
+			cooldown:  c.cfg.OpenTimeout,
+			now:       time.Now,
+		}}
+		c.upstreams[service] = u
+	}
+	return u
+}
+
+// Do sends a request to path on an instance of service. body may be nil;
+// when it is not it is sent as JSON. Each attempt resolves the service
+// again, so a retry can land on a different instance.
+//
+// A response is returned for any status code. The caller must close its
+// body.
+func (c *Client) Do(ctx context.Context, service, method, path string, body []byte) (*http.Response, error) {
+	u := c.upstream(service)
+	attempts := 1
+	if isIdempotent(method) {
+		attempts += c.cfg.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			u.retries.Add(1)
+			if err := c.sleep(ctx, attempt); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := u.breaker.allow(); err != nil {
+			u.rejected.Add(1)
+			return nil, fmt.Errorf("%v: %w", service, err)
+		}
+
+		res, err := c.attempt(ctx, u, service, method, path, body)
+		failed := err != nil || res.StatusCode >= http.StatusInternalServerError
+		if failed && ctx.Err() != nil {
+			// The caller gave up or went away, which says nothing about
+			// the upstream.
+			u.breaker.abandon()
+			return res, err
+		}
+		u.breaker.record(!failed)
+		if !failed {
+			return res, nil
+		}
+		u.failures.Add(1)
+
+		if attempt == attempts-1 || !retryable(res, err) {
+			return res, err
+		}
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			lastErr = fmt.Errorf("%v: status %v", service, res.StatusCode)
+		} else {
+			lastErr = err
+		}
+		log.Printf("Retrying %v %v on %v service: %v", method, path, service, lastErr)
+	}
+	return nil, lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, u *upstream, service, method, path string, body []byte) (*http.Response, error) {
+	base, err := c.resolver.Resolve(service)
+	if err != nil {
+		return nil, err
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if c.cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+	}
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path, r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for k, v := range c.cfg.Header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	u.requests.Add(1)
+	start := time.Now()
+	res, err := c.http.Do(req)
+	u.latency.Add(int64(time.Since(start)))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The attempt's deadline has to outlive Do so the caller can read
+	// the body; it is released when the body is closed.
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// backoff is the longest wait before the given retry: BaseBackoff,
+// doubled for every retry before it, up to MaxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.cfg.BaseBackoff
+	for i := 1; i < attempt && backoff < c.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff <= 0 || backoff > c.cfg.MaxBackoff {
+		backoff = c.cfg.MaxBackoff
+	}
+	return backoff
+}
+
+// sleep waits for a random duration up to the backoff for this attempt
+// ("full jitter"), or until ctx is done.
+func (c *Client) sleep(ctx context.Context, attempt int) error {
+	backoff := c.backoff(attempt)
+	if backoff <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(time.Duration(rand.Int63n(int64(backoff))))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// State reports the circuit breaker state for service.
+func (c *Client) State(service string) State {
+	return c.upstream(service).breaker.current()
+}
+
+// Stats returns a snapshot of every upstream the client has called.
+func (c *Client) Stats() map[string]Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[string]Stats, len(c.upstreams))
+	for name, u := range c.upstreams {
+		s := Stats{
+			Requests: u.requests.Load(),
+			Failures: u.failures.Load(),
+			Retries:  u.retries.Load(),
+			Rejected: u.rejected.Load(),
+			State:    u.breaker.current(),
+		}
+		if s.Requests > 0 {
+			s.AvgLatencyMS = float64(u.latency.Load()) / float64(s.Requests) / float64(time.Millisecond)
+		}
+		result[name] = s
+	}
+	return result
+}
+
+// Handler serves Stats in the representation the request accepts.
+func (c *Client) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		render.Respond(w, r, http.StatusOK, c.Stats())
+	})
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a failed attempt is worth repeating: transport
+// errors and the statuses that mean "try again later".
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
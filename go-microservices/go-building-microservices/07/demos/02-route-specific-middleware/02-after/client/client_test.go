@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"demo/registry"
+)
+
+// failing returns an upstream that answers every request with status and
+// counts the requests.
+func failing(t *testing.T, status int) (*httptest.Server, *atomic.Int64) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.BaseBackoff = time.Millisecond
+	cfg.MaxBackoff = time.Millisecond
+	return cfg
+}
+
+func TestRetriesOnlyIdempotentMethods(t *testing.T) {
+	tests := map[string]int64{
+		http.MethodGet:    3,
+		http.MethodPut:    3,
+		http.MethodDelete: 3,
+		http.MethodPost:   1,
+		http.MethodPatch:  1,
+	}
+	for method, want := range tests {
+		srv, hits := failing(t, http.StatusServiceUnavailable)
+		c := New(registry.Static{"up": {srv.URL}}, testConfig())
+		res, err := c.Do(context.Background(), "up", method, "/", nil)
+		if err == nil {
+			res.Body.Close()
+		}
+		if hits.Load() != want {
+			t.Errorf("%v: %d attempts, want %d", method, hits.Load(), want)
+		}
+	}
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	srv, hits := failing(t, http.StatusNotFound)
+	c := New(registry.Static{"up": {srv.URL}}, testConfig())
+	res, err := c.Do(context.Background(), "up", http.MethodGet, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if hits.Load() != 1 || c.State("up") != Closed {
+		t.Errorf("404: %d attempts, breaker %v, want 1 attempt and closed", hits.Load(), c.State("up"))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New(nil, Config{BaseBackoff: 50 * time.Millisecond, MaxBackoff: time.Second})
+	tests := map[int]time.Duration{
+		1:   50 * time.Millisecond,
+		2:   100 * time.Millisecond,
+		5:   800 * time.Millisecond,
+		6:   time.Second,
+		100: time.Second,
+	}
+	for attempt, want := range tests {
+		if got := c.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestCircuitOpensAfterFailures(t *testing.T) {
+	srv, hits := failing(t, http.StatusInternalServerError)
+	cfg := testConfig()
+	cfg.FailureThreshold = 2
+	c := New(registry.Static{"up": {srv.URL}}, cfg)
+
+	for i := 0; i < 2; i++ {
+		if res, err := c.Do(context.Background(), "up", http.MethodPost, "/", nil); err == nil {
+			res.Body.Close()
+		}
+	}
+	_, err := c.Do(context.Background(), "up", http.MethodPost, "/", nil)
+	if !errors.Is(err, ErrCircuitOpen) || hits.Load() != 2 {
+		t.Errorf("third call: %v after %d attempts, want ErrCircuitOpen after 2", err, hits.Load())
+	}
+}
+
+func TestCancelledCallsDoNotOpenTheCircuit(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cfg := testConfig()
+	cfg.FailureThreshold = 1
+	c := New(registry.Static{"up": {srv.URL}}, cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Do(ctx, "up", http.MethodGet, "/", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the caller's deadline", err)
+	}
+	if s := c.Stats()["up"]; c.State("up") != Closed || s.Failures != 0 || s.Retries != 0 {
+		t.Errorf("after the caller gave up: breaker %v, stats %+v", c.State("up"), s)
+	}
+}
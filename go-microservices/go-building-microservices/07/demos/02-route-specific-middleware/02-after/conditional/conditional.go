@@ -0,0 +1,148 @@
+// Package conditional implements entity tags and conditional requests
+// (RFC 9110 section 13) for JSON resources, the way http.ServeContent does
+// for files.
+package conditional
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"demo/render"
+	"shared/middleware"
+	"shared/problem"
+)
+
+// ETag returns a strong entity tag for the representation of v that r
+// negotiates. It is derived from v's JSON encoding, so it changes whenever
+// the resource's content does, and from the media type, since a strong
+// tag may only be shared by identical bodies and the JSON and XML of a
+// resource are not.
+func ETag(r *http.Request, v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	mediaType, _ := render.Negotiate(r.Header.Get("Accept"), render.Offers(v))
+	return etag(mediaType, data), nil
+}
+
+func etag(mediaType string, data []byte) string {
+	h := sha256.New()
+	io.WriteString(h, mediaType+"\n")
+	h.Write(data)
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// NotModified sets the ETag header and evaluates If-None-Match for a GET
+// or HEAD. When the client's copy is current it writes 304 Not Modified and
+// returns true, and the caller must not write a body. The 304 carries
+// Vary: Accept, as the full response would, so caches keep the tag with
+// the right representation.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	inm := r.Header.Get("If-None-Match")
+	if inm == "" || !matches(inm, etag, false) {
+		return false
+	}
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// CheckIfMatch evaluates If-Match against current, the state of a
+// resource that is about to be changed, and returns a 412 problem when the
+// client was working from a stale copy. Requests without If-Match pass.
+//
+// The tag of any of current's representations matches, so a client may
+// read a resource as XML and then write it back as JSON.
+func CheckIfMatch(r *http.Request, current any) error {
+	im := r.Header.Get("If-Match")
+	if im == "" {
+		return nil
+	}
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	for _, mediaType := range render.Offers(current) {
+		if matches(im, etag(mediaType, data), true) {
+			return nil
+		}
+	}
+	mediaType, _ := render.Negotiate(r.Header.Get("Accept"), render.Offers(current))
+	return problem.New(http.StatusPreconditionFailed, "resource has changed; its current ETag is %v", etag(mediaType, data))
+}
+
+// matches reports whether the header list contains etag or "*". Strong
+// comparison, used for If-Match, never matches a weak tag.
+func matches(header, etag string, strong bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if strong {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheControl sets the Cache-Control header of successful GET and HEAD
+// responses on a route, for example:
+//
+//	router.Handle("/products/", h, conditional.CacheControl("public, max-age=60"))
+//
+// Errors are left alone so that a 404 is not cached as long as the
+// resource would be.
+func CacheControl(value string) middleware.Middleware {
+	return middleware.New("cache-control", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(&cacheWriter{ResponseWriter: w, value: value}, r)
+		})
+	})
+}
+
+type cacheWriter struct {
+	http.ResponseWriter
+	value       string
+	wroteHeader bool
+}
+
+func (w *cacheWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if (status == http.StatusOK || status == http.StatusNotModified) && w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", w.value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,98 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"shared/problem"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+func get(accept, ifNoneMatch string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	r.Header.Set("Accept", accept)
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	return r
+}
+
+func TestETagDependsOnMediaType(t *testing.T) {
+	v := item{ID: 1, Name: "one"}
+	jsonTag, _ := ETag(get("application/json", ""), v)
+	xmlTag, _ := ETag(get("application/xml", ""), v)
+	anyTag, _ := ETag(get("*/*", ""), v)
+	if jsonTag == xmlTag {
+		t.Errorf("JSON and XML share the tag %v", jsonTag)
+	}
+	if anyTag != jsonTag {
+		t.Errorf("*/* tag = %v, want the JSON tag %v it is served as", anyTag, jsonTag)
+	}
+	if changed, _ := ETag(get("application/json", ""), item{ID: 1, Name: "uno"}); changed == jsonTag {
+		t.Error("tag did not change with the content")
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	v := item{ID: 1, Name: "one"}
+	jsonTag, _ := ETag(get("application/json", ""), v)
+
+	// A tag for the JSON must not validate a cached XML copy.
+	r := get("application/xml", jsonTag)
+	xmlTag, _ := ETag(r, v)
+	if w := httptest.NewRecorder(); NotModified(w, r, xmlTag) {
+		t.Error("XML request revalidated with the JSON tag")
+	}
+
+	r = get("application/json", jsonTag)
+	w := httptest.NewRecorder()
+	if !NotModified(w, r, jsonTag) {
+		t.Fatal("matching If-None-Match was not a 304")
+	}
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary = %q, want Accept", got)
+	}
+	if got := w.Header().Get("ETag"); got != jsonTag {
+		t.Errorf("ETag = %q, want %q", got, jsonTag)
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	v := item{ID: 1, Name: "one"}
+	xmlTag, _ := ETag(get("application/xml", ""), v)
+
+	put := func(ifMatch string) *http.Request {
+		r := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "application/json")
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		return r
+	}
+
+	// A tag read as XML still matches a change sent and answered as JSON.
+	for _, ifMatch := range []string{"", xmlTag, `"stale", ` + xmlTag, "*"} {
+		if err := CheckIfMatch(put(ifMatch), v); err != nil {
+			t.Errorf("If-Match %v: %v, want a match", ifMatch, err)
+		}
+	}
+
+	changed := item{ID: 1, Name: "uno"}
+	for _, ifMatch := range []string{xmlTag, "W/" + xmlTag} {
+		err := CheckIfMatch(put(ifMatch), changed)
+		var p *problem.Error
+		if !errors.As(err, &p) || p.Status != http.StatusPreconditionFailed {
+			t.Errorf("If-Match %v on a changed item: %v, want 412", ifMatch, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// csvMapper finds columns by header name rather than position, so a file
+// with reordered or extra columns still loads.
+type csvMapper struct {
+	header  []string
+	columns map[string]int
+}
+
+// newCSVMapper indexes header. Names are matched case-insensitively and
+// ignoring surrounding space. Every name in required must be present.
+func newCSVMapper(header []string, required ...string) (*csvMapper, error) {
+	m := &csvMapper{header: header, columns: make(map[string]int, len(header))}
+	for i, name := range header {
+		key := normalizeColumn(name)
+		if _, dup := m.columns[key]; dup {
+			return nil, &RowError{Line: 1, Column: name, Err: errors.New("duplicate column")}
+		}
+		m.columns[key] = i
+	}
+	for _, name := range required {
+		if _, ok := m.columns[normalizeColumn(name)]; !ok {
+			return nil, &RowError{Line: 1, Column: name, Err: errors.New("missing required column")}
+		}
+	}
+	return m, nil
+}
+
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+// get returns the value of the named column in record, or "" if the
+// record is too short to have it.
+func (m *csvMapper) get(record []string, name string) string {
+	i, ok := m.columns[normalizeColumn(name)]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return record[i]
+}
+
+// set returns a copy of record, padded to the width of the header, with
+// the named column replaced.
+func (m *csvMapper) set(record []string, name, value string) []string {
+	result := make([]string, len(m.header))
+	copy(result, record)
+	if i, ok := m.columns[normalizeColumn(name)]; ok {
+		result[i] = value
+	}
+	return result
+}
+
+// RowError describes one problem found while importing a CSV file.
+type RowError struct {
+	Line   int    `json:"line"`
+	Column string `json:"column,omitempty"`
+	Err    error  `json:"-"`
+}
+
+func (e *RowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("line %d, column %q: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON includes the message of the underlying error, which has no
+// JSON form of its own.
+func (e *RowError) MarshalJSON() ([]byte, error) {
+	type rowError RowError
+	return json.Marshal(struct {
+		*rowError
+		Message string `json:"message"`
+	}{(*rowError)(e), e.Err.Error()})
+}
+
+// ImportError collects every RowError from one import.
+type ImportError struct {
+	Errors []*RowError
+}
+
+func (e *ImportError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", e.Errors[0], len(e.Errors)-1)
+}
+
+func (e *ImportError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+type importMode string
+
+const (
+	// importStrict refuses to load a file with any invalid row.
+	importStrict importMode = "strict"
+	// importLenient skips invalid rows and reports them.
+	importLenient importMode = "lenient"
+)
+
+func parseImportMode(s string) (importMode, error) {
+	switch importMode(s) {
+	case importStrict, importLenient:
+		return importMode(s), nil
+	case "":
+		return importLenient, nil
+	}
+	return "", fmt.Errorf("unknown import mode %q, want %q or %q", s, importStrict, importLenient)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCSVMapper(t *testing.T) {
+	m, err := newCSVMapper([]string{"\ufeffAddress", " ID ", "notes"}, "id", "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := []string{"1 Main St", "7", "vip"}
+	if got := m.get(record, "id"); got != "7" {
+		t.Errorf("get id = %q, want %q", got, "7")
+	}
+	if got := m.get(record, "address"); got != "1 Main St" {
+		t.Errorf("get address = %q, want %q", got, "1 Main St")
+	}
+	if got := m.get(record[:1], "notes"); got != "" {
+		t.Errorf("get from short record = %q, want empty", got)
+	}
+	if got := m.get(record, "missing"); got != "" {
+		t.Errorf("get unknown column = %q, want empty", got)
+	}
+
+	got := m.set(record[:1], "id", "8")
+	if want := []string{"1 Main St", "8", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("set = %q, want %q", got, want)
+	}
+	if record[1] != "7" {
+		t.Error("set modified the record it was given")
+	}
+}
+
+func TestCSVMapperHeaderErrors(t *testing.T) {
+	tests := map[string]struct {
+		header []string
+		want   string
+	}{
+		"duplicate": {[]string{"id", "ID"}, `line 1, column "ID": duplicate column`},
+		"missing":   {[]string{"id"}, `line 1, column "address": missing required column`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := newCSVMapper(tt.header, "id", "address")
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
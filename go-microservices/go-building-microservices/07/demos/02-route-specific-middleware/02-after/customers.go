@@ -0,0 +1,629 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"demo/conditional"
+	"demo/health"
+	"demo/render"
+	"shared/metrics"
+	"shared/middleware"
+	"shared/problem"
+	"shared/trace"
+)
+
+type Customer struct {
+	ID        int    `json:"id" xml:"id"`
+	FirstName string `json:"firstName" xml:"firstName"`
+	LastName  string `json:"lastName" xml:"lastName"`
+	Address   string `json:"address" xml:"address"`
+}
+
+func (c Customer) CSVHeader() []string {
+	return defaultCustomerHeader
+}
+
+func (c Customer) CSVRecord() []string {
+	return []string{strconv.Itoa(c.ID), c.FirstName, c.LastName, c.Address}
+}
+
+var ErrCustomerNotFound = errors.New("customer not found")
+var errInvalidCustomer = errors.New("customer must have a first and last name")
+
+// customerCacheControl applies to single customers, which are personal
+// data: clients may keep a copy but must check it is current before using
+// it.
+const customerCacheControl = "private, no-cache"
+
+func createCustomerService(addr string, checks *health.Checker, tracer *trace.Tracer) *http.Server {
+
+	mode, err := parseImportMode(os.Getenv("CUSTOMER_IMPORT_MODE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	store, err := openCustomerStore("customers.csv", mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if report := store.Report(); len(report.Errors) > 0 {
+		log.Printf("Skipped %d of %d rows in customers.csv; see /admin/import", report.Skipped, report.Rows)
+	}
+	checks.AddReadiness("customers.csv", store.Check)
+
+	reg := newMetrics()
+	router := middleware.NewRouter(trace.Middleware(tracer), middleware.Logging(loggingConfig()), metrics.Middleware(reg))
+
+	router.Handle("/customers", problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			page, err := customerList.page(w, r, store.List())
+			if err != nil {
+				return pageError(err)
+			}
+			render.Respond(w, r, http.StatusOK, page)
+		case http.MethodPost:
+			if err := render.Acceptable(r, Customer{}); err != nil {
+				return err
+			}
+			c, err := decodeCustomer(r)
+			if err != nil {
+				return err
+			}
+			c, err = store.Create(c)
+			if err != nil {
+				return customerError(err)
+			}
+			w.Header().Set("Location", fmt.Sprintf("/customers/%v", c.ID))
+			render.Respond(w, r, http.StatusCreated, c)
+		default:
+			return problem.MethodNotAllowed(r)
+		}
+		return nil
+	}))
+
+	pattern := regexp.MustCompile(`^\/customers\/(\d+?)$`)
+	router.Handle("/customers/", problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		matches := pattern.FindStringSubmatch(r.URL.Path)
+		if len(matches) == 0 {
+			return problem.NotFound(r)
+		}
+
+		id, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return problem.BadRequest("invalid customer ID %q", matches[1])
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			c, err := store.Get(id)
+			if err != nil {
+				return customerError(err)
+			}
+			etag, err := conditional.ETag(r, c)
+			if err != nil {
+				return err
+			}
+			if conditional.NotModified(w, r, etag) {
+				return nil
+			}
+			render.Respond(w, r, http.StatusOK, c)
+		case http.MethodPut:
+			if err := render.Acceptable(r, Customer{}); err != nil {
+				return err
+			}
+			c, err := decodeCustomer(r)
+			if err != nil {
+				return err
+			}
+			c.ID = id
+			c, err = store.Update(c, ifMatch(r))
+			if err != nil {
+				return customerError(err)
+			}
+			etag, err := conditional.ETag(r, c)
+			if err != nil {
+				return err
+			}
+			w.Header().Set("ETag", etag)
+			render.Respond(w, r, http.StatusOK, c)
+		case http.MethodDelete:
+			if err := store.Delete(id, ifMatch(r)); err != nil {
+				return customerError(err)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			return problem.MethodNotAllowed(r)
+		}
+		return nil
+	}), conditional.CacheControl(customerCacheControl))
+
+	router.Handle("/admin/import", problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method != http.MethodGet {
+			return problem.MethodNotAllowed(r)
+		}
+		render.Respond(w, r, http.StatusOK, store.Report())
+		return nil
+	}))
+
+	router.Handle("/metrics", reg.Handler())
+	router.Handle("/healthz", checks.LivenessHandler())
+	router.Handle("/readyz", checks.ReadinessHandler())
+	router.Handle("/", problem.NotFoundHandler())
+
+	s := http.Server{
+		Addr:    addr,
+		Handler: router,
+	}
+
+	return &s
+
+}
+
+var customerList = listSpec[Customer]{
+	fields: map[string]func(Customer) any{
+		"id":        func(c Customer) any { return c.ID },
+		"firstName": func(c Customer) any { return c.FirstName },
+		"lastName":  func(c Customer) any { return c.LastName },
+		"address":   func(c Customer) any { return c.Address },
+	},
+	filters: map[string]func(string) (func(Customer) bool, error){
+		"firstName": equalsFold(func(c Customer) string { return c.FirstName }),
+		"lastName":  equalsFold(func(c Customer) string { return c.LastName }),
+	},
+	id: func(c Customer) int { return c.ID },
+}
+
+func decodeCustomer(r *http.Request) (Customer, error) {
+	var c Customer
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		return Customer{}, problem.BadRequest("invalid customer: %v", err)
+	}
+	return c, nil
+}
+
+// ifMatch returns a store precondition that enforces the request's
+// If-Match header against the customer being changed.
+func ifMatch(r *http.Request) func(current Customer) error {
+	return func(current Customer) error {
+		return conditional.CheckIfMatch(r, current)
+	}
+}
+
+// customerError maps errors from the store to the problem reported to the
+// client.
+func customerError(err error) error {
+	switch {
+	case errors.Is(err, ErrCustomerNotFound):
+		return problem.Wrap(http.StatusNotFound, err)
+	case errors.Is(err, errInvalidCustomer):
+		return problem.Wrap(http.StatusBadRequest, err)
+	}
+	return err
+}
+
+// customerFile is the parsed contents of customers.csv. Rows that fail
+// validation are kept in raw, and records that cannot be parsed at all
+// keep their original text, so rewriting the file never loses them.
+type customerFile struct {
+	mapper *csvMapper
+	rows   []customerRow
+	report importReport
+	format csvFormat
+	// tail is an unparseable record at the very end of the file. It is
+	// written back after every row, added ones included.
+	tail []byte
+}
+
+// csvFormat is how a CSV file was written, so rewriting it only changes
+// the rows that changed.
+type csvFormat struct {
+	crlf     bool
+	quoteAll bool
+}
+
+// detectCSVFormat looks at the header line of data.
+func detectCSVFormat(data []byte) csvFormat {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	line = bytes.TrimPrefix(line, []byte("\ufeff"))
+	return csvFormat{
+		crlf:     bytes.HasSuffix(line, []byte("\r")),
+		quoteAll: bytes.HasPrefix(line, []byte(`"`)),
+	}
+}
+
+// record returns the text of lines first to last of data, numbered from
+// 1, and whether the record runs to the end of the file.
+func (format csvFormat) record(data []byte, first, last int) (text []byte, atEnd bool) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if last > len(lines) {
+		last = len(lines)
+	}
+	text = bytes.Join(lines[first-1:last], nil)
+	atEnd = len(bytes.Join(lines[last:], nil)) == 0
+	if !atEnd && !bytes.HasSuffix(text, []byte("\n")) {
+		text = append(text, '\n')
+	}
+	return text, atEnd
+}
+
+// write appends record to buf. Fields are quoted when the format quotes
+// every field, or when they could not be read back otherwise.
+func (format csvFormat) write(buf *bytes.Buffer, record []string) {
+	for i, field := range record {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if format.quoteAll || strings.ContainsAny(field, ",\"\r\n") || strings.TrimLeft(field, " \t") != field {
+			buf.WriteByte('"')
+			buf.WriteString(strings.ReplaceAll(field, `"`, `""`))
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(field)
+		}
+	}
+	if format.crlf {
+		buf.WriteString("\r\n")
+	} else {
+		buf.WriteByte('\n')
+	}
+}
+
+type customerRow struct {
+	raw      []string
+	customer Customer
+	valid    bool
+	// text holds the lines of a record the CSV reader rejected. It is
+	// written back unchanged.
+	text []byte
+}
+
+// importReport describes the last time the CSV file was read.
+type importReport struct {
+	Mode     importMode  `json:"mode"`
+	LoadedAt time.Time   `json:"loadedAt"`
+	Rows     int         `json:"rows"`
+	Imported int         `json:"imported"`
+	Skipped  int         `json:"skipped"`
+	Errors   []*RowError `json:"errors"`
+}
+
+func (f *customerFile) customers() []Customer {
+	customers := make([]Customer, 0, len(f.rows))
+	for _, row := range f.rows {
+		if row.valid {
+			customers = append(customers, row.customer)
+		}
+	}
+	return customers
+}
+
+func (f *customerFile) find(id int) int {
+	for i, row := range f.rows {
+		if row.valid && row.customer.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextID is one past the highest ID in the file. Skipped rows count too,
+// so fixing one by hand later cannot collide with a newer customer.
+func (f *customerFile) nextID() int {
+	next := 1
+	for _, row := range f.rows {
+		id := row.customer.ID
+		if !row.valid {
+			id, _ = strconv.Atoi(strings.TrimSpace(f.mapper.get(row.raw, colID)))
+		}
+		if id >= next {
+			next = id + 1
+		}
+	}
+	return next
+}
+
+func (f *customerFile) encode() []byte {
+	var buf bytes.Buffer
+	f.format.write(&buf, f.mapper.header)
+	for _, row := range f.rows {
+		if row.text != nil {
+			buf.Write(row.text)
+			continue
+		}
+		record := row.raw
+		if row.valid {
+			c := row.customer
+			record = f.mapper.set(record, colID, strconv.Itoa(c.ID))
+			record = f.mapper.set(record, colFirstName, c.FirstName)
+			record = f.mapper.set(record, colLastName, c.LastName)
+			record = f.mapper.set(record, colAddress, c.Address)
+		}
+		f.format.write(&buf, record)
+	}
+	buf.Write(f.tail)
+	return buf.Bytes()
+}
+
+const (
+	colID        = "id"
+	colFirstName = "first_name"
+	colLastName  = "last_name"
+	colAddress   = "address"
+)
+
+var defaultCustomerHeader = []string{colID, colFirstName, colLastName, colAddress}
+
+func emptyCustomerFile(mode importMode) *customerFile {
+	m, _ := newCSVMapper(defaultCustomerHeader)
+	return &customerFile{
+		mapper: m,
+		report: importReport{Mode: mode, LoadedAt: time.Now(), Errors: []*RowError{}},
+		// New files are written like the customers.csv shipped with the
+		// demo.
+		format: csvFormat{crlf: true, quoteAll: true},
+	}
+}
+
+// readCustomers maps the columns of a customers CSV by header name and
+// validates every row. In strict mode any invalid row fails the whole
+// import with an *ImportError listing all of them; in lenient mode
+// invalid rows are skipped and listed in the file's report. A header
+// without the required columns is an error in either mode.
+func readCustomers(r io.Reader, mode importMode) (*customerFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.FieldsPerRecord = -1
+	f := emptyCustomerFile(mode)
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.format = detectCSVFormat(data)
+	f.mapper, err = newCSVMapper(header, colID, colFirstName, colLastName, colAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]int)
+	for {
+		fields, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// A malformed quote leaves nothing usable in the record;
+			// report it, keep its lines as they are and carry on with
+			// the next record.
+			f.report.Errors = append(f.report.Errors, &RowError{Line: parseErr.Line, Err: parseErr.Err})
+			f.report.Rows++
+			f.report.Skipped++
+			text, atEnd := f.format.record(data, parseErr.StartLine, parseErr.Line)
+			if atEnd {
+				// An unterminated quote swallows everything after it,
+				// so it has to stay last.
+				f.tail = text
+			} else {
+				f.rows = append(f.rows, customerRow{text: text})
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := csvReader.FieldPos(0)
+		f.report.Rows++
+
+		row := customerRow{raw: fields}
+		c, rowErr := f.mapCustomer(line, fields, seen)
+		if rowErr != nil {
+			f.report.Errors = append(f.report.Errors, rowErr)
+			f.report.Skipped++
+		} else {
+			row.customer = c
+			row.valid = true
+			seen[c.ID] = line
+			f.report.Imported++
+		}
+		f.rows = append(f.rows, row)
+	}
+
+	if mode == importStrict && len(f.report.Errors) > 0 {
+		return nil, &ImportError{Errors: f.report.Errors}
+	}
+	return f, nil
+}
+
+// mapCustomer validates one record. seen maps the IDs already imported to
+// the line they were found on.
+func (f *customerFile) mapCustomer(line int, fields []string, seen map[int]int) (Customer, *RowError) {
+	if len(fields) != len(f.mapper.header) {
+		return Customer{}, &RowError{Line: line, Err: fmt.Errorf("has %d fields, header has %d", len(fields), len(f.mapper.header))}
+	}
+
+	rawID := strings.TrimSpace(f.mapper.get(fields, colID))
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id <= 0 {
+		return Customer{}, &RowError{Line: line, Column: colID, Err: fmt.Errorf("%q is not a positive integer", rawID)}
+	}
+	if first, dup := seen[id]; dup {
+		return Customer{}, &RowError{Line: line, Column: colID, Err: fmt.Errorf("duplicate ID %d, first seen on line %d", id, first)}
+	}
+
+	c := Customer{
+		ID:        id,
+		FirstName: strings.TrimSpace(f.mapper.get(fields, colFirstName)),
+		LastName:  strings.TrimSpace(f.mapper.get(fields, colLastName)),
+		Address:   strings.TrimSpace(f.mapper.get(fields, colAddress)),
+	}
+	if c.FirstName == "" {
+		return Customer{}, &RowError{Line: line, Column: colFirstName, Err: errors.New("is empty")}
+	}
+	if c.LastName == "" {
+		return Customer{}, &RowError{Line: line, Column: colLastName, Err: errors.New("is empty")}
+	}
+	return c, nil
+}
+
+// customerStore serves customers from memory and writes every change
+// back to the CSV file. Writes take an exclusive lock on the file and
+// re-read it first, so several instances sharing one file do not
+// overwrite each other's changes or hand out the same ID.
+type customerStore struct {
+	path string
+	mode importMode
+
+	mu   sync.RWMutex
+	file *customerFile
+}
+
+func openCustomerStore(path string, mode importMode) (*customerStore, error) {
+	s := &customerStore{path: path, mode: mode}
+	f, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.file = f
+	return s, nil
+}
+
+func (s *customerStore) load() (*customerFile, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return emptyCustomerFile(s.mode), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readCustomers(f, s.mode)
+}
+
+func (s *customerStore) List() []Customer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.file.customers()
+}
+
+// Check reports whether the CSV file can still be read, as a readiness
+// check.
+func (s *customerStore) Check(ctx context.Context) error {
+	_, err := s.load()
+	return err
+}
+
+// Report describes the last import of the CSV file.
+func (s *customerStore) Report() importReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.file.report
+}
+
+func (s *customerStore) Get(id int) (Customer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.file.find(id)
+	if i < 0 {
+		return Customer{}, ErrCustomerNotFound
+	}
+	return s.file.rows[i].customer, nil
+}
+
+func (s *customerStore) Create(c Customer) (Customer, error) {
+	if c.FirstName == "" || c.LastName == "" {
+		return Customer{}, errInvalidCustomer
+	}
+	err := s.modify(func(f *customerFile) error {
+		c.ID = f.nextID()
+		f.rows = append(f.rows, customerRow{customer: c, valid: true})
+		return nil
+	})
+	return c, err
+}
+
+// Update replaces the customer with c's ID. When precondition is not nil
+// it is called with the stored customer, under the store's lock, and an
+// error from it aborts the update.
+func (s *customerStore) Update(c Customer, precondition func(current Customer) error) (Customer, error) {
+	if c.FirstName == "" || c.LastName == "" {
+		return Customer{}, errInvalidCustomer
+	}
+	err := s.modify(func(f *customerFile) error {
+		i := f.find(c.ID)
+		if i < 0 {
+			return ErrCustomerNotFound
+		}
+		if precondition != nil {
+			if err := precondition(f.rows[i].customer); err != nil {
+				return err
+			}
+		}
+		f.rows[i].customer = c
+		return nil
+	})
+	return c, err
+}
+
+// Delete removes the customer, subject to precondition as for Update.
+func (s *customerStore) Delete(id int, precondition func(current Customer) error) error {
+	return s.modify(func(f *customerFile) error {
+		i := f.find(id)
+		if i < 0 {
+			return ErrCustomerNotFound
+		}
+		if precondition != nil {
+			if err := precondition(f.rows[i].customer); err != nil {
+				return err
+			}
+		}
+		f.rows = append(f.rows[:i], f.rows[i+1:]...)
+		return nil
+	})
+}
+
+// modify re-reads the file under lock, applies fn and atomically replaces
+// the file with the result.
+func (s *customerStore) modify(fn func(f *customerFile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, f.encode()); err != nil {
+		return err
+	}
+	s.file = f
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCustomerFileRoundTrip(t *testing.T) {
+	shipped, err := os.ReadFile("customers.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"shipped file": string(shipped),
+		"minimal quoting": "id,first_name,last_name,address\n" +
+			"1,John,Smith,\"123 Main St, Anytown\"\n" +
+			"2,Emily,\"Johnson \"\"Em\"\"\",\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := readCustomers(strings.NewReader(data), importLenient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(f.encode()); got != data {
+				t.Errorf("encode changed the file:\ngot  %q\nwant %q", got, data)
+			}
+		})
+	}
+}
+
+func TestCustomerFileKeepsFormat(t *testing.T) {
+	data := "\"id\",\"first_name\",\"last_name\",\"address\"\r\n\"1\",\"John\",\"Smith\",\"\"\r\n"
+	f, err := readCustomers(strings.NewReader(data), importLenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.rows = append(f.rows, customerRow{customer: Customer{ID: 2, FirstName: "Emily", LastName: "Johnson"}, valid: true})
+
+	want := data + "\"2\",\"Emily\",\"Johnson\",\"\"\r\n"
+	if got := string(f.encode()); got != want {
+		t.Errorf("encode = %q, want %q", got, want)
+	}
+}
+
+func TestCustomerFileKeepsUnparseableRecords(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		broken  string
+		skipped int
+		valid   int
+	}{
+		"bare quote": {
+			data: "id,first_name,last_name,address\n" +
+				"1,Jo\"hn,Smith,x\n" +
+				"2,Emily,Johnson,y\n",
+			broken:  "1,Jo\"hn,Smith,x\n",
+			skipped: 1, valid: 1,
+		},
+		"extra quote across lines": {
+			data: "id,first_name,last_name,address\n" +
+				"1,John,Smith,\"line one\nline \"two\"\n" +
+				"2,Emily,Johnson,y\n",
+			broken:  "1,John,Smith,\"line one\nline \"two\"\n",
+			skipped: 1, valid: 1,
+		},
+		"unterminated quote at end": {
+			data: "id,first_name,last_name,address\r\n" +
+				"1,John,Smith,x\r\n" +
+				"2,Emily,Johnson,\"y",
+			broken:  "2,Emily,Johnson,\"y",
+			skipped: 1, valid: 1,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := readCustomers(strings.NewReader(tt.data), importLenient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.report.Skipped != tt.skipped || len(f.customers()) != tt.valid {
+				t.Fatalf("skipped %d and loaded %d customers, want %d and %d", f.report.Skipped, len(f.customers()), tt.skipped, tt.valid)
+			}
+
+			// Adding a customer must leave the broken record in place.
+			f.rows = append(f.rows, customerRow{customer: Customer{ID: 3, FirstName: "Sarah", LastName: "Brown"}, valid: true})
+			encoded := string(f.encode())
+			if !strings.Contains(encoded, tt.broken) {
+				t.Errorf("encode = %q, want it to keep %q", encoded, tt.broken)
+			}
+
+			again, err := readCustomers(strings.NewReader(encoded), importLenient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.report.Skipped != tt.skipped || len(again.customers()) != tt.valid+1 {
+				t.Errorf("re-read skipped %d and loaded %d customers, want %d and %d", again.report.Skipped, len(again.customers()), tt.skipped, tt.valid+1)
+			}
+		})
+	}
+}
+
+func TestReadCustomersValidation(t *testing.T) {
+	data := "Address, ID ,First_Name,last_name,notes\n" +
+		"x,1,John,Smith,\n" +
+		"y,1,Emily,Johnson,\n" +
+		"z,abc,Michael,Williams,\n" +
+		"w,4,,Brown,\n" +
+		"v,5,David\n" +
+		"u,6,Maria,Martinez,vip\n"
+
+	f, err := readCustomers(strings.NewReader(data), importLenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantErrors := []string{
+		`line 3, column "id": duplicate ID 1, first seen on line 2`,
+		`line 4, column "id": "abc" is not a positive integer`,
+		`line 5, column "first_name": is empty`,
+		`line 6: has 3 fields, header has 5`,
+	}
+	var gotErrors []string
+	for _, e := range f.report.Errors {
+		gotErrors = append(gotErrors, e.Error())
+	}
+	if strings.Join(gotErrors, "\n") != strings.Join(wantErrors, "\n") {
+		t.Errorf("errors:\n%v\nwant:\n%v", strings.Join(gotErrors, "\n"), strings.Join(wantErrors, "\n"))
+	}
+	if got := f.customers(); len(got) != 2 || got[1] != (Customer{ID: 6, FirstName: "Maria", LastName: "Martinez", Address: "u"}) {
+		t.Errorf("customers = %v", got)
+	}
+	if got := f.nextID(); got != 7 {
+		t.Errorf("nextID = %d, want 7", got)
+	}
+
+	_, err = readCustomers(strings.NewReader(data), importStrict)
+	if ie, ok := err.(*ImportError); !ok || len(ie.Errors) != len(wantErrors) {
+		t.Errorf("strict import error = %v, want an ImportError with %d rows", err, len(wantErrors))
+	}
+}
@@ -1,3 +1,19 @@
 module demo
 
 go 1.21.0
+
+require (
+	google.golang.org/protobuf v1.31.0
+	shared v0.0.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.14.0 // indirect
+	golang.org/x/sys v0.11.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
+	google.golang.org/grpc v1.59.0 // indirect
+)
+
+replace shared => ../../../../shared
@@ -0,0 +1,198 @@
+// Package health serves liveness and readiness endpoints backed by named
+// checks that each service registers.
+//
+// Liveness (/healthz) answers "should this process be restarted?" and
+// should only fail when the process cannot recover on its own. Readiness
+// (/readyz) answers "should this process receive traffic?" and covers
+// dependencies such as data files and upstream services.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"demo/render"
+)
+
+const (
+	DefaultTimeout  = time.Second
+	DefaultCacheTTL = 2 * time.Second
+)
+
+// CheckFunc reports a problem by returning an error. It should give up
+// when ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// Checker holds the checks of one service.
+type Checker struct {
+	// Timeout bounds each check. A check still running when it expires
+	// fails.
+	Timeout time.Duration
+	// CacheTTL is how long a result is reused, so that frequent probes do
+	// not hammer the dependencies they check.
+	CacheTTL time.Duration
+
+	mu        sync.Mutex
+	liveness  []*check
+	readiness []*check
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+
+	mu     sync.Mutex
+	result Result
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name      string    `json:"name" xml:"name"`
+	Status    string    `json:"status" xml:"status"`
+	Error     string    `json:"error,omitempty" xml:"error,omitempty"`
+	Duration  string    `json:"duration" xml:"duration"`
+	CheckedAt time.Time `json:"checkedAt" xml:"checkedAt"`
+}
+
+// Report is the body of a health endpoint.
+type Report struct {
+	Status string   `json:"status" xml:"status"`
+	Checks []Result `json:"checks" xml:"check"`
+}
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+func NewChecker() *Checker {
+	return &Checker{
+		Timeout:  DefaultTimeout,
+		CacheTTL: DefaultCacheTTL,
+	}
+}
+
+// AddLiveness registers a check that fails /healthz.
+func (c *Checker) AddLiveness(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.liveness = append(c.liveness, &check{name: name, fn: fn})
+}
+
+// AddReadiness registers a check that fails /readyz.
+func (c *Checker) AddReadiness(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readiness = append(c.readiness, &check{name: name, fn: fn})
+}
+
+// Live runs the liveness checks.
+func (c *Checker) Live(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := c.liveness
+	c.mu.Unlock()
+	return c.run(ctx, checks)
+}
+
+// Ready runs the liveness and readiness checks: a process that is not
+// live is not ready either.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append(append([]*check(nil), c.liveness...), c.readiness...)
+	c.mu.Unlock()
+	return c.run(ctx, checks)
+}
+
+// LivenessHandler serves Live, with 503 when any check fails.
+func (c *Checker) LivenessHandler() http.Handler {
+	return c.handler(c.Live)
+}
+
+// ReadinessHandler serves Ready, with 503 when any check fails.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return c.handler(c.Ready)
+}
+
+func (c *Checker) handler(run func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+		w.Header().Set("Cache-Control", "no-store")
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		render.Respond(w, r, status, report)
+	})
+}
+
+// run evaluates checks concurrently.
+func (c *Checker) run(ctx context.Context, checks []*check) Report {
+	report := Report{Status: StatusOK, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk *check) {
+			defer wg.Done()
+			report.Checks[i] = c.evaluate(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// evaluate returns chk's cached result if it is fresh enough, and runs it
+// otherwise. Concurrent callers wait for a single run rather than each
+// starting their own.
+func (c *Checker) evaluate(ctx context.Context, chk *check) Result {
+	chk.mu.Lock()
+	defer chk.mu.Unlock()
+	if !chk.result.CheckedAt.IsZero() && time.Since(chk.result.CheckedAt) < c.CacheTTL {
+		return chk.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v", c.Timeout)
+		}
+	}
+
+	result := Result{Name: chk.name, Status: StatusOK, Duration: time.Since(start).String(), CheckedAt: start}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	// A check cut short because the prober went away says nothing about
+	// the dependency, so it is not cached.
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		chk.result = result
+	}
+	return result
+}
@@ -0,0 +1,272 @@
+// Package idempotency makes retried POST requests safe. A client sends a
+// unique Idempotency-Key with a request; the first response for that key
+// is stored, and repeats of the request get the stored response instead
+// of being processed again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"shared/middleware"
+	"shared/problem"
+)
+
+const (
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses served from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength bounds the keys clients may send.
+const maxKeyLength = 255
+
+// maxBodySize bounds the bodies read into memory to fingerprint and store.
+const maxBodySize = 1 << 20
+
+// ErrMismatch means a key was reused for a different request.
+var ErrMismatch = errors.New("idempotency key was already used for a different request")
+
+// Response is a stored response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store records the requests seen for each key.
+type Store interface {
+	// Begin claims key for the request with the given fingerprint. It
+	// returns the stored response if the request has completed before,
+	// waits if it is still in flight, and returns nil if the caller now
+	// owns the key and must call Complete.
+	Begin(ctx context.Context, key, fingerprint string) (*Response, error)
+	// Complete stores res for key. A nil res releases the key without
+	// storing anything, so that a retry is processed afresh.
+	Complete(key string, res *Response)
+}
+
+// MemoryStore keeps responses in memory for TTL after they complete.
+type MemoryStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// expiries lists completed entries in the order they complete. The
+	// TTL is the same for all of them, so that is also the order they
+	// expire in, and expiring only ever looks at the front.
+	expiries []expiry
+}
+
+type expiry struct {
+	key   string
+	entry *entry
+}
+
+type entry struct {
+	fingerprint string
+	// done is closed when the request completes or is abandoned.
+	done    chan struct{}
+	res     *Response
+	expires time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{ttl: ttl, now: time.Now, entries: make(map[string]*entry)}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	for {
+		s.mu.Lock()
+		s.expire(s.now())
+		e, ok := s.entries[key]
+		if !ok {
+			s.entries[key] = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+			s.mu.Unlock()
+			return nil, nil
+		}
+		if e.fingerprint != fingerprint {
+			s.mu.Unlock()
+			return nil, ErrMismatch
+		}
+		if e.res != nil {
+			s.mu.Unlock()
+			return e.res, nil
+		}
+		done := e.done
+		s.mu.Unlock()
+
+		select {
+		case <-done:
+			// Completed or abandoned: look again.
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *MemoryStore) Complete(key string, res *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || e.res != nil {
+		return
+	}
+	if res == nil {
+		delete(s.entries, key)
+	} else {
+		e.res = res
+		e.expires = s.now().Add(s.ttl)
+		s.expiries = append(s.expiries, expiry{key: key, entry: e})
+	}
+	close(e.done)
+}
+
+// expire drops completed entries past their TTL. The caller must hold
+// s.mu.
+func (s *MemoryStore) expire(now time.Time) {
+	n := 0
+	for n < len(s.expiries) && now.After(s.expiries[n].entry.expires) {
+		x := s.expiries[n]
+		if s.entries[x.key] == x.entry {
+			delete(s.entries, x.key)
+		}
+		s.expiries[n] = expiry{}
+		n++
+	}
+	s.expiries = s.expiries[n:]
+}
+
+// Middleware applies Idempotency-Key handling to POST and PATCH requests
+// that carry the header; other requests pass straight through. Keys are
+// scoped to the client that client returns for the request, so one client
+// can neither replay nor block another's requests by guessing its keys.
+//
+// A request whose key was seen with a different method, path or body is
+// refused with 422. A repeat that arrives while the original is still
+// being processed waits for it and then gets its response. Responses with
+// a 5xx status are not stored, so the client can retry them.
+func Middleware(store Store, client func(r *http.Request) string) middleware.Middleware {
+	return middleware.New("idempotency", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				problem.Write(w, r, problem.BadRequest("%v must be at most %d characters", Header, maxKeyLength))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problem.Write(w, r, problem.New(http.StatusRequestEntityTooLarge, "request body must be at most %d bytes", tooLarge.Limit))
+				return
+			}
+			if err != nil {
+				problem.Write(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key = client(r) + "\x00" + key
+			res, err := store.Begin(r.Context(), key, fingerprint(r, body))
+			switch {
+			case errors.Is(err, ErrMismatch):
+				problem.Write(w, r, problem.Wrap(http.StatusUnprocessableEntity, err))
+				return
+			case err != nil:
+				// The client went away while waiting for the original.
+				return
+			case res != nil:
+				replay(w, res)
+				return
+			}
+
+			before := w.Header().Clone()
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					store.Complete(key, nil)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+
+			if rec.status < http.StatusInternalServerError {
+				store.Complete(key, &Response{Status: rec.status, Header: added(before, rec.Header()), Body: rec.body.Bytes()})
+			} else {
+				store.Complete(key, nil)
+			}
+			completed = true
+		})
+	})
+}
+
+// fingerprint identifies what a request asks for, so a key reused for a
+// different request can be told apart from a retry.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// added returns the headers in after that are not in before: those the
+// handler set, as opposed to middlewares further out, which will set their
+// own on a replay.
+func added(before, after http.Header) http.Header {
+	h := make(http.Header)
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			h[name] = slices.Clone(values)
+		}
+	}
+	return h
+}
+
+func replay(w http.ResponseWriter, res *Response) {
+	for name, values := range res.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(res.Status)
+	w.Write(res.Body)
+}
+
+// recorder passes a response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,139 @@
+package idempotency
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counter creates a resource for every request it handles and reports
+// which one in its response.
+type counter struct{ n int }
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.n++
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, "created %d", c.n)
+}
+
+func byRemoteAddr(r *http.Request) string { return r.RemoteAddr }
+
+func post(h http.Handler, client, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/carts", strings.NewReader(body))
+	r.RemoteAddr = client
+	r.Header.Set(Header, key)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddlewareReplays(t *testing.T) {
+	c := &counter{}
+	h := Middleware(NewMemoryStore(time.Hour), byRemoteAddr).Wrap(c)
+
+	first := post(h, "a", "k1", "{}")
+	again := post(h, "a", "k1", "{}")
+	if c.n != 1 {
+		t.Errorf("handler ran %d times, want 1", c.n)
+	}
+	if again.Code != first.Code || again.Body.String() != first.Body.String() || again.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("repeat got %d %q, want a replay of %d %q", again.Code, again.Body, first.Code, first.Body)
+	}
+
+	if w := post(h, "a", "k1", `{"other":true}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with a different body: status %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestMiddlewareConcurrentRepeat(t *testing.T) {
+	var runs atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	h := Middleware(NewMemoryStore(time.Hour), byRemoteAddr).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := runs.Add(1)
+		if n == 1 {
+			close(started)
+		}
+		<-release
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "created %d", n)
+	}))
+
+	first, again := make(chan *httptest.ResponseRecorder), make(chan *httptest.ResponseRecorder)
+	go func() { first <- post(h, "a", "k1", "{}") }()
+	<-started
+	go func() { again <- post(h, "a", "k1", "{}") }()
+
+	// The repeat waits for the original rather than running the handler
+	// or failing.
+	select {
+	case w := <-again:
+		t.Fatalf("repeat returned %d %q while the original was in flight", w.Code, w.Body)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	a, b := <-first, <-again
+	if n := runs.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+	if a.Code != http.StatusCreated || b.Code != a.Code || b.Body.String() != a.Body.String() {
+		t.Errorf("responses %d %q and %d %q, want the same 201", a.Code, a.Body, b.Code, b.Body)
+	}
+}
+
+func TestMiddlewareRejectsLargeBodies(t *testing.T) {
+	c := &counter{}
+	h := Middleware(NewMemoryStore(time.Hour), byRemoteAddr).Wrap(c)
+
+	w := post(h, "a", "k1", strings.Repeat(" ", maxBodySize+1))
+	if w.Code != http.StatusRequestEntityTooLarge || c.n != 0 {
+		t.Errorf("oversized body: status %d and handler ran %d times, want %d and 0", w.Code, c.n, http.StatusRequestEntityTooLarge)
+	}
+	if w := post(h, "a", "k1", "{}"); w.Code != http.StatusCreated {
+		t.Errorf("key of a refused request: status %d, want %d", w.Code, http.StatusCreated)
+	}
+}
+
+func TestMiddlewareScopesKeysByClient(t *testing.T) {
+	c := &counter{}
+	h := Middleware(NewMemoryStore(time.Hour), byRemoteAddr).Wrap(c)
+
+	post(h, "a", "k1", "{}")
+	w := post(h, "b", "k1", "{}")
+	if c.n != 2 || w.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("another client's key was replayed: handler ran %d times, body %q", c.n, w.Body)
+	}
+	if w := post(h, "c", "k1", `{"other":true}`); w.Code != http.StatusCreated {
+		t.Errorf("a key used by other clients blocked a different request: status %d", w.Code)
+	}
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore(time.Minute)
+	s.now = func() time.Time { return now }
+	h := Middleware(s, byRemoteAddr).Wrap(&counter{})
+
+	post(h, "a", "k1", "{}")
+	now = now.Add(30 * time.Second)
+	post(h, "a", "k2", "{}")
+	now = now.Add(31 * time.Second)
+
+	// Only k1 is past its TTL; looking up any key drops it.
+	post(h, "a", "k3", "{}")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries["a\x00k1"]; ok {
+		t.Error("expired entry k1 is still stored")
+	}
+	if _, ok := s.entries["a\x00k2"]; !ok {
+		t.Error("entry k2 expired early")
+	}
+	if len(s.expiries) != 2 {
+		t.Errorf("%d entries waiting to expire, want 2", len(s.expiries))
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"demo/validator"
+	"shared/problem"
+)
+
+const defaultPageSize = 20
+
+var (
+	limitValidator  = validator.NewIntegerValidator(1, 100)
+	offsetValidator = validator.NewIntegerValidator(0, math.MaxInt32)
+)
+
+// listSpec describes how a collection can be filtered and sorted through
+// query parameters.
+type listSpec[T any] struct {
+	// fields maps sortable field names to the value to sort by, which
+	// must be an int, float64 or string.
+	fields map[string]func(T) any
+	// filters maps query parameters to a function that parses the
+	// parameter and returns the predicate items must satisfy.
+	filters map[string]func(value string) (func(T) bool, error)
+	id      func(T) int
+}
+
+// listCursor marks the last item of a page. The next page starts with the
+// first item that sorts after it, so inserts and deletes between requests
+// do not shift items across pages the way an offset would.
+type listCursor struct {
+	Sort string  `json:"sort"`
+	S    string  `json:"s,omitempty"`
+	N    float64 `json:"n,omitempty"`
+	ID   int     `json:"id"`
+}
+
+func (c listCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// page filters, sorts and slices items according to the request's query
+// string:
+//
+//	limit=1..100      page size, default 20
+//	offset=n          skip n items
+//	cursor=...        continue after the page that returned this cursor;
+//	                  an empty cursor starts from the first page
+//	sort=field        ascending; sort=-field for descending
+//	<filter>=value    one of the spec's filters
+//
+// Any other parameter is an error, so a misspelt filter does not silently
+// return the whole collection. It sets X-Total-Count to the number of
+// items that matched the filters and a Link header with the first,
+// previous and next pages.
+func (spec listSpec[T]) page(w http.ResponseWriter, r *http.Request, items []T) ([]T, error) {
+	q := r.URL.Query()
+	if err := spec.checkParams(q); err != nil {
+		return nil, err
+	}
+
+	limit := defaultPageSize
+	if v := q.Get("limit"); v != "" {
+		n, err := limitValidator.Validate(v)
+		if err != nil {
+			return nil, fmt.Errorf("limit: %w", err)
+		}
+		limit = n
+	}
+
+	matched := make([]T, 0, len(items))
+	var preds []func(T) bool
+	for param, parse := range spec.filters {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		pred, err := parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", param, err)
+		}
+		preds = append(preds, pred)
+	}
+items:
+	for _, item := range items {
+		for _, pred := range preds {
+			if !pred(item) {
+				continue items
+			}
+		}
+		matched = append(matched, item)
+	}
+
+	sortParam := q.Get("sort")
+	field := strings.TrimPrefix(sortParam, "-")
+	desc := strings.HasPrefix(sortParam, "-")
+	key, ok := spec.fields[field]
+	if field == "" {
+		key, ok = func(item T) any { return spec.id(item) }, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("sort: unknown field %q", field)
+	}
+	less := func(a, b T) bool {
+		c := compareValues(key(a), key(b))
+		if c == 0 {
+			c = spec.id(a) - spec.id(b)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	start := 0
+	cursorMode := q.Has("cursor")
+	switch {
+	case cursorMode && q.Has("offset"):
+		return nil, fmt.Errorf("offset and cursor cannot be combined")
+	case cursorMode && q.Get("cursor") == "":
+		// An empty cursor starts cursor pagination from the first page.
+	case cursorMode:
+		c, err := decodeCursor(q.Get("cursor"))
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != sortParam {
+			return nil, fmt.Errorf("cursor was issued for sort=%q", c.Sort)
+		}
+		start = sort.Search(len(matched), func(i int) bool {
+			v := key(matched[i])
+			cmp := compareToCursor(v, c)
+			if cmp == 0 {
+				cmp = spec.id(matched[i]) - c.ID
+			}
+			if desc {
+				cmp = -cmp
+			}
+			return cmp > 0
+		})
+	case q.Has("offset"):
+		n, err := offsetValidator.Validate(q.Get("offset"))
+		if err != nil {
+			return nil, fmt.Errorf("offset: %w", err)
+		}
+		start = min(n, len(matched))
+	}
+	end := min(start+limit, len(matched))
+	result := matched[start:end]
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(matched)))
+
+	// The first page of a cursor walk is an empty cursor, so following
+	// rel="first" stays in cursor mode.
+	first := func(q url.Values) { q.Del("cursor"); q.Del("offset") }
+	if cursorMode {
+		first = func(q url.Values) { q.Set("cursor", "") }
+	}
+	links := []string{pageLink(r, "first", first)}
+	if cursorMode {
+		if end < len(matched) {
+			last := result[len(result)-1]
+			next := cursorFor(key(last), spec.id(last), sortParam).encode()
+			links = append(links, pageLink(r, "next", func(q url.Values) { q.Set("cursor", next) }))
+		}
+	} else {
+		if start > 0 {
+			prev := max(start-limit, 0)
+			links = append(links, pageLink(r, "prev", func(q url.Values) { q.Set("offset", strconv.Itoa(prev)) }))
+		}
+		if end < len(matched) {
+			links = append(links, pageLink(r, "next", func(q url.Values) { q.Set("offset", strconv.Itoa(end)) }))
+		}
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+
+	return result, nil
+}
+
+// checkParams rejects query parameters that are neither paging
+// parameters nor one of the spec's filters.
+func (spec listSpec[T]) checkParams(q url.Values) error {
+	var unknown []string
+	for param := range q {
+		switch param {
+		case "limit", "offset", "cursor", "sort":
+			continue
+		}
+		if _, ok := spec.filters[param]; !ok {
+			unknown = append(unknown, strconv.Quote(param))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown query parameter %v", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func pageLink(r *http.Request, rel string, edit func(q url.Values)) string {
+	u := *r.URL
+	q := u.Query()
+	edit(q)
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf("<%v>; rel=%q", u.RequestURI(), rel)
+}
+
+func cursorFor(v any, id int, sortParam string) listCursor {
+	c := listCursor{Sort: sortParam, ID: id}
+	switch v := v.(type) {
+	case int:
+		c.N = float64(v)
+	case float64:
+		c.N = v
+	case string:
+		c.S = v
+	}
+	return c
+}
+
+func compareToCursor(v any, c listCursor) int {
+	switch v := v.(type) {
+	case int:
+		return compareValues(float64(v), c.N)
+	case float64:
+		return compareValues(v, c.N)
+	case string:
+		return compareValues(v, c.S)
+	}
+	return 0
+}
+
+// compareValues orders two values of the same kind. Strings compare
+// case-insensitively.
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case int:
+		return compareOrdered(a, b.(int))
+	case float64:
+		return compareOrdered(a, b.(float64))
+	case string:
+		return compareOrdered(strings.ToLower(a), strings.ToLower(b.(string)))
+	}
+	return 0
+}
+
+func compareOrdered[V int | float64 | string](a, b V) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// equalsFold builds a filter matching a string field case-insensitively.
+func equalsFold[T any](field func(T) string) func(string) (func(T) bool, error) {
+	return func(value string) (func(T) bool, error) {
+		return func(item T) bool { return strings.EqualFold(field(item), value) }, nil
+	}
+}
+
+// priceBound builds a filter comparing a price field against a decimal
+// amount such as 2.50, using cents so 1.99 never compares unequal to
+// itself.
+func priceBound[T any](field func(T) float64, atMost bool) func(string) (func(T) bool, error) {
+	return func(value string) (func(T) bool, error) {
+		bound, err := parseMoney(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(item T) bool {
+			price := moneyFromUSD(field(item))
+			if atMost {
+				return price <= bound
+			}
+			return price >= bound
+		}, nil
+	}
+}
+
+// pageError reports invalid list parameters to the client.
+func pageError(err error) error {
+	return problem.Wrap(http.StatusBadRequest, err)
+}
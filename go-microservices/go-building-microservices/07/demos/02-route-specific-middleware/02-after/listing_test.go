@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testProducts = []Product{
+	{ID: 1, Name: "Apple", USDPerUnit: 0.5, Unit: "each"},
+	{ID: 2, Name: "Banana", USDPerUnit: 0.25, Unit: "each"},
+	{ID: 3, Name: "Cherry", USDPerUnit: 4, Unit: "pound"},
+	{ID: 4, Name: "Date", USDPerUnit: 6, Unit: "pound"},
+	{ID: 5, Name: "Elderberry", USDPerUnit: 4, Unit: "pound"},
+}
+
+// listPage requests target and returns the IDs on the page and its links
+// by rel.
+func listPage(t *testing.T, target string) ([]int, map[string]string, error) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	page, err := productList.page(w, httptest.NewRequest("GET", target, nil), testProducts)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := []int{}
+	for _, p := range page {
+		ids = append(ids, p.ID)
+	}
+	links := make(map[string]string)
+	for _, m := range regexp.MustCompile(`<([^>]*)>; rel="(\w+)"`).FindAllStringSubmatch(w.Header().Get("Link"), -1) {
+		links[m[2]] = m[1]
+	}
+	return ids, links, nil
+}
+
+func TestPageOffset(t *testing.T) {
+	ids, links, err := listPage(t, "/products?limit=2&offset=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 4}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("page = %v, want %v", ids, want)
+	}
+	want := map[string]string{
+		"first": "/products?limit=2",
+		"prev":  "/products?limit=2&offset=0",
+		"next":  "/products?limit=2&offset=4",
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("links = %v, want %v", links, want)
+	}
+}
+
+func TestPageFilterAndSort(t *testing.T) {
+	ids, _, err := listPage(t, "/products?unit=POUND&minPrice=4.00&sort=-usdPerUnit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ties on price are broken by ID, in the same direction.
+	if want := []int{4, 5, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("page = %v, want %v", ids, want)
+	}
+}
+
+func TestPageCursor(t *testing.T) {
+	target := "/products?limit=2&sort=-name&cursor="
+	var all []int
+	for i := 0; target != ""; i++ {
+		if i > 5 {
+			t.Fatal("cursor walk did not end")
+		}
+		ids, links, err := listPage(t, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, ids...)
+		if first := links["first"]; first != "/products?cursor=&limit=2&sort=-name" {
+			t.Errorf("first link = %q, want one that keeps cursor paging", first)
+		}
+		if _, ok := links["prev"]; ok {
+			t.Error("cursor pages should not link to a previous page")
+		}
+		target = links["next"]
+	}
+	if want := []int{5, 4, 3, 2, 1}; !reflect.DeepEqual(all, want) {
+		t.Errorf("walked %v, want %v", all, want)
+	}
+}
+
+func TestPageErrors(t *testing.T) {
+	_, links, err := listPage(t, "/products?limit=2&sort=name&cursor=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, _ := url.Parse(links["next"])
+	cursor := next.Query().Get("cursor")
+
+	tests := map[string]string{
+		"/products?colour=red":                  `unknown query parameter "colour"`,
+		"/products?Name=apple&zz=1":             `unknown query parameter "Name", "zz"`,
+		"/products?sort=weight":                 `sort: unknown field "weight"`,
+		"/products?limit=0":                     "limit:",
+		"/products?offset=1&cursor=":            "offset and cursor cannot be combined",
+		"/products?cursor=%21%21":               "invalid cursor",
+		"/products?sort=-name&cursor=" + cursor: `cursor was issued for sort="name"`,
+		"/products?minPrice=cheap":              "minPrice:",
+		"/products?minPrice=1.-5":               "minPrice:",
+		"/products?maxPrice=--1":                "maxPrice:",
+	}
+	for target, want := range tests {
+		_, _, err := listPage(t, target)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: error = %v, want %q", target, err, want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+// lockFile is a no-op where flock is unavailable. Writers in the same
+// process are still serialised by the store's mutex.
+func lockFile(path string) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+
+	"demo/client"
+	"demo/health"
+	"shared/catalog"
+	"shared/problem"
+)
+
+// maxProductLookups bounds how many requests a single cart request may
+// have in flight against the product service at once.
+const maxProductLookups = 4
+
+// upstreamError reports that another service could not be reached or
+// failed to answer. Handlers turn it into a 502 rather than a 500, since
+// the cart service itself is healthy.
+type upstreamError struct {
+	Service string
+	Err     error
+	// RetryAfter is the upstream's Retry-After when it refused the call
+	// with a 429, and empty otherwise.
+	RetryAfter string
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("%v service unavailable: %v", e.Service, e.Err)
+}
+
+func (e *upstreamError) Unwrap() error {
+	return e.Err
+}
+
+// Names the services register under.
+const (
+	customerServiceName = "customer"
+	productServiceName  = "product"
+	cartServiceName     = "cart"
+)
+
+// internalTokenHeader carries the token that marks a request as coming
+// from another service in this process.
+const internalTokenHeader = "X-Internal-Token"
+
+// newInternalToken returns a random token for the services to mark their
+// calls to each other with. It is made afresh for every run and never
+// leaves the process.
+func newInternalToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// upstreams is how the cart service reaches the customer and product
+// services. Every call goes through the shared client, which resolves the
+// service by name and applies timeouts, retries and circuit breaking.
+type upstreams struct {
+	client *client.Client
+}
+
+// unknownReferenceType identifies the 400 returned when a request refers
+// to customers or products that do not exist. The offending IDs are
+// listed in invalidCustomerId and invalidProductIds.
+const unknownReferenceType = "/problems/unknown-reference"
+
+func unknownReferences(detail string, customerID int, productIDs []int) *problem.Error {
+	err := &problem.Error{Status: http.StatusBadRequest, Type: unknownReferenceType, Detail: detail}
+	if customerID != 0 {
+		err.With("invalidCustomerId", customerID)
+	}
+	if len(productIDs) > 0 {
+		err.With("invalidProductIds", productIDs)
+	}
+	return err
+}
+
+// ping returns a health check that fails unless the service answers its
+// liveness endpoint. Liveness rather than readiness keeps one unready
+// service from marking every service that calls it unready too.
+func (u *upstreams) ping(service string) health.CheckFunc {
+	return func(ctx context.Context) error {
+		res, err := u.client.Do(ctx, service, http.MethodGet, "/healthz", nil)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("%v service: status %v", service, res.StatusCode)
+		}
+		return nil
+	}
+}
+
+// checkCustomer returns ErrCustomerNotFound, as the customer store does,
+// when the customer service does not know id.
+func (u *upstreams) checkCustomer(ctx context.Context, id int) error {
+	res, err := u.client.Do(ctx, customerServiceName, http.MethodHead, fmt.Sprintf("/customers/%v", id), nil)
+	if err != nil {
+		return &upstreamError{Service: customerServiceName, Err: err}
+	}
+	res.Body.Close()
+
+	// Only a success proves the customer exists. Any other answer, such
+	// as a 401 or 429, says nothing about it either way.
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusNotFound:
+		return ErrCustomerNotFound
+	case res.StatusCode == http.StatusTooManyRequests:
+		return &upstreamError{
+			Service:    customerServiceName,
+			Err:        fmt.Errorf("rate limited checking customer %v", id),
+			RetryAfter: res.Header.Get("Retry-After"),
+		}
+	}
+	return &upstreamError{Service: customerServiceName, Err: fmt.Errorf("status %v for customer %v", res.StatusCode, id)}
+}
+
+// fetchProduct returns catalog.ErrNotFound for an unknown product, the
+// error the store returns and catalog.FromStatus recovers from the gRPC
+// service, so a missing product is handled the same way whichever way it
+// was looked up.
+func (u *upstreams) fetchProduct(ctx context.Context, id int) (Product, error) {
+	res, err := u.client.Do(ctx, productServiceName, http.MethodGet, fmt.Sprintf("/products/%v", id), nil)
+	if err != nil {
+		return Product{}, &upstreamError{Service: productServiceName, Err: err}
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Product{}, catalog.ErrNotFound
+	case http.StatusTooManyRequests:
+		return Product{}, &upstreamError{
+			Service:    productServiceName,
+			Err:        fmt.Errorf("rate limited looking up product %v", id),
+			RetryAfter: res.Header.Get("Retry-After"),
+		}
+	default:
+		return Product{}, &upstreamError{Service: productServiceName, Err: fmt.Errorf("status %v for product %v", res.StatusCode, id)}
+	}
+
+	var p Product
+	if err := json.NewDecoder(res.Body).Decode(&p); err != nil {
+		return Product{}, &upstreamError{Service: productServiceName, Err: err}
+	}
+	return p, nil
+}
+
+// lookupProducts fetches every distinct ID concurrently, with at most
+// maxProductLookups requests in flight. IDs the product service does not
+// know are returned in ascending order in unknown; any other failure
+// aborts the lookup.
+func (u *upstreams) lookupProducts(ctx context.Context, ids []int) (found map[int]Product, unknown []int, err error) {
+	found = make(map[int]Product)
+	seen := make(map[int]bool)
+	sem := make(chan struct{}, maxProductLookups)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			p, lookupErr := u.fetchProduct(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(lookupErr, catalog.ErrNotFound):
+				unknown = append(unknown, id)
+			case lookupErr != nil:
+				if err == nil {
+					err = lookupErr
+				}
+			default:
+				found[id] = p
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Ints(unknown)
+	return found, unknown, nil
+}
+
+// lookupError maps a failed customer or product lookup to the problem
+// reported to the client: unknown IDs are the client's fault, everything
+// else is blamed on the upstream service. An upstream that is rate
+// limiting the cart service is only busy, so the client gets a 503 and the
+// upstream's Retry-After rather than a 502. Upstream errors can name
+// internal addresses, so they are logged rather than passed on.
+func lookupError(w http.ResponseWriter, err error) error {
+	var upstream *upstreamError
+	var unknown *unknownProductsError
+	switch {
+	case errors.As(err, &unknown):
+		return unknownReferences("cart references unknown products", 0, unknown.IDs)
+	case errors.As(err, &upstream):
+		log.Printf("Lookup failed: %v", err)
+		if upstream.RetryAfter != "" {
+			w.Header().Set("Retry-After", upstream.RetryAfter)
+			return problem.New(http.StatusServiceUnavailable, "%v service busy", upstream.Service)
+		}
+		return problem.New(http.StatusBadGateway, "%v service unavailable", upstream.Service)
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"demo/client"
+	"demo/registry"
+	"shared/problem"
+)
+
+func TestLookupRateLimited(t *testing.T) {
+	var token string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get(internalTokenHeader)
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer upstream.Close()
+
+	cfg := client.DefaultConfig()
+	cfg.Header = http.Header{internalTokenHeader: {"secret"}}
+	up := &upstreams{client: client.New(registry.Static{productServiceName: {upstream.URL}}, cfg)}
+
+	_, _, err := up.lookupProducts(context.Background(), []int{1})
+	if token != "secret" {
+		t.Errorf("upstream saw token %q, want %q", token, "secret")
+	}
+
+	w := httptest.NewRecorder()
+	var p *problem.Error
+	if !errors.As(lookupError(w, err), &p) || p.Status != http.StatusServiceUnavailable {
+		t.Fatalf("lookupError = %v, want a 503", p)
+	}
+	if got := w.Header().Get("Retry-After"); got != "7" {
+		t.Errorf("Retry-After = %q, want %q", got, "7")
+	}
+}
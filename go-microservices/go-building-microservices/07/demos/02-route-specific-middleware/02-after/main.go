@@ -3,8 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,209 +12,304 @@ import (
 	"regexp"
 	"strconv"
 	"time"
+
+	"demo/conditional"
+	"demo/health"
+	"demo/productpb"
+	"demo/registry"
+	"demo/render"
+	"shared/catalog"
+	"shared/lifecycle"
+	"shared/metrics"
+	"shared/middleware"
+	"shared/problem"
+	"shared/ratelimit"
+	"shared/trace"
+
+	"google.golang.org/protobuf/proto"
 )
 
 func main() {
-	cs := createCustomerService()
-	ps := createProductService()
-	scs := createShoppingCartService()
-
-	go func() {
-		cs.ListenAndServe()
-	}()
+	store, err := openFileCartStore("cart-data")
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	go func() {
-		ps.ListenAndServe()
-	}()
+	// Instances listed in services.json or SERVICE_<NAME>_URLS are known up
+	// front; the services started below add themselves as they come up.
+	static, err := registry.LoadFile(envOr("SERVICES_CONFIG", "services.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	reg := registry.NewLocal(static.Merge(registry.FromEnv(os.Environ())))
 
-	go func() {
-		scs.ListenAndServe()
-	}()
+	customerAddr := envOr("CUSTOMER_SERVICE_ADDR", ":3000")
+	productAddr := envOr("PRODUCT_SERVICE_ADDR", ":4000")
+	cartAddr := envOr("CART_SERVICE_ADDR", ":5000")
 
-	time.Sleep(1 * time.Second)
+	shutdownTimeout, err := time.ParseDuration(envOr("SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	http.Post("http://localhost:5000/carts", "applcation/json",
-		bytes.NewBufferString(`
-				{
-					"id": 1,
-					"customerId": 4,
-					"productIds: [ 1, 3 ]
-				}
-			`))
+	// Spans go to TRACES_FILE as OTLP/JSON when it is set. Otherwise they
+	// are dropped, but trace context still flows between the services and
+	// into their logs.
+	var exporter trace.Exporter
+	if path := os.Getenv("TRACES_FILE"); path != "" {
+		fe, err := trace.NewFileExporter(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fe.Close()
+		exporter = fe
+	}
 
-	res, err := http.Get("http://localhost:5000/carts")
+	// Rate limits are shared through RATE_LIMIT_FILE when it is set, so
+	// several instances on one machine enforce them together.
+	var limits ratelimit.Store = ratelimit.NewMemoryStore()
+	if path := os.Getenv("RATE_LIMIT_FILE"); path != "" {
+		limits = ratelimit.NewFileStore(path)
+	}
+	// The cart service's product lookups carry a token made for this run,
+	// so they are limited apart from the clients whose carts they check.
+	internalToken, err := newInternalToken()
 	if err != nil {
 		log.Fatal(err)
 	}
-	data, err := io.ReadAll(res.Body)
+	limiter := ratelimit.NewLimiter(limits, ratelimit.Internal(internalTokenHeader, internalToken, ratelimit.ByIP))
+
+	sup := lifecycle.New()
+	sup.ShutdownTimeout = shutdownTimeout
+	sup.Add(customerServiceName, createCustomerService(customerAddr, newChecker(sup), trace.NewTracer(customerServiceName, exporter)))
+	sup.Add(productServiceName, createProductService(productAddr, catalog.NewStore(toCatalog(products)), newChecker(sup), trace.NewTracer(productServiceName, exporter), limiter))
+	sup.Add(cartServiceName, createShoppingCartService(cartAddr, store, reg, newChecker(sup), trace.NewTracer(cartServiceName, exporter), limiter, internalToken))
+
+	sup.OnStarted(func() {
+		reg.Register(customerServiceName, registry.URLFor(customerAddr))
+		reg.Register(productServiceName, registry.URLFor(productAddr))
+		reg.Register(cartServiceName, registry.URLFor(cartAddr))
+	})
+	sup.OnStarted(func() {
+		seedDemoCart(reg, store)
+		fmt.Println("Services started, press Ctrl+C to shutdown")
+	})
+	sup.OnShutdown(func() {
+		reg.Deregister(cartServiceName, registry.URLFor(cartAddr))
+		reg.Deregister(productServiceName, registry.URLFor(productAddr))
+		reg.Deregister(customerServiceName, registry.URLFor(customerAddr))
+	})
+
+	err = sup.Run(context.Background())
+	store.Close()
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Println("Response: ", string(data))
-
-	fmt.Println("Services started, press <Enter> to shutdown")
-	fmt.Scanln()
-	cs.Shutdown(context.Background())
-	ps.Shutdown(context.Background())
-	scs.Shutdown(context.Background())
 	fmt.Println("Services stopped")
 }
 
-type Product struct {
-	ID         int
-	Name       string
-	USDPerUnit float64
-	Unit       string
+// newMetrics returns a registry with the Go runtime metrics every service
+// exposes.
+func newMetrics() *metrics.Registry {
+	reg := metrics.NewRegistry()
+	reg.Register(metrics.NewRuntimeCollector())
+	return reg
 }
 
-func createProductService() *http.Server {
-
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/products", func(w http.ResponseWriter, r *http.Request) {
-		data, err := json.Marshal(products)
-		if err != nil {
-			log.Fatal(err)
+// newChecker returns the health checks every service shares: the process
+// is live while all of its servers are serving, and ready until shutdown
+// begins.
+func newChecker(sup *lifecycle.Supervisor) *health.Checker {
+	checks := health.NewChecker()
+	checks.AddLiveness("servers", func(ctx context.Context) error {
+		if !sup.Alive() {
+			return errors.New("not all servers are serving")
 		}
-		w.Header().Add("Content-Type", "application/json")
-
-		w.Write(data)
+		return nil
 	})
-
-	pattern := regexp.MustCompile(`^\/products\/(\d+?)$`)
-	mux.HandleFunc("/products/", func(w http.ResponseWriter, r *http.Request) {
-		matches := pattern.FindStringSubmatch(r.URL.Path)
-		if len(matches) == 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			return
+	checks.AddReadiness("lifecycle", func(ctx context.Context) error {
+		if !sup.Ready() {
+			return errors.New("shutting down")
 		}
+		return nil
+	})
+	return checks
+}
 
-		id, err := strconv.Atoi(matches[1])
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
+// seedDemoCart posts a sample cart, unless store still holds carts from
+// an earlier run, and prints the cart list.
+func seedDemoCart(reg registry.Resolver, store CartStore) {
+	cartURL, err := reg.Resolve(cartServiceName)
+	if err != nil {
+		log.Print(err)
+		return
+	}
 
-		for _, p := range products {
-			if id == p.ID {
-				data, err := json.Marshal(p)
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
+	carts, err := store.List()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	if len(carts) == 0 {
+		http.Post(cartURL+"/carts", "application/json",
+			bytes.NewBufferString(`
+				{
+					"id": 1,
+					"customerId": 4,
+					"items": [
+						{ "productId": 1, "quantity": 2 },
+						{ "productId": 3, "quantity": 1 }
+					]
 				}
-				w.Header().Add("Content-Type", "application/json")
-				w.Write(data)
-				return
-			}
-		}
-		w.WriteHeader(http.StatusNotFound)
+			`))
+	}
 
-	})
+	res, err := http.Get(cartURL + "/carts")
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	log.Println("Response: ", string(data))
+}
 
-	s := http.Server{
-		Addr:    ":4000",
-		Handler: mux,
+// loggingConfig reads the request logging settings shared by all three
+// services. LOG_SAMPLE_RATE is the fraction of successful requests to log
+// and LOG_HEADERS=1 adds the (redacted) request headers to each line.
+func loggingConfig() middleware.LoggingConfig {
+	cfg := middleware.DefaultLoggingConfig()
+	if v, err := strconv.ParseFloat(os.Getenv("LOG_SAMPLE_RATE"), 64); err == nil {
+		cfg.SampleRate = v
 	}
+	cfg.LogHeaders = os.Getenv("LOG_HEADERS") == "1"
+	cfg.Attrs = trace.LogAttrs
+	return cfg
+}
 
-	return &s
+// envOr returns the value of the environment variable key, or def if it is
+// unset or empty.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
 }
 
-type Customer struct {
-	ID        int    `json:"id"`
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Address   string `json:"address"`
+// Product is the catalogue's product as this service renders it.
+type Product catalog.Product
+
+func (p Product) CSVHeader() []string {
+	return []string{"id", "name", "usdPerUnit", "unit"}
 }
 
-func createCustomerService() *http.Server {
+func (p Product) CSVRecord() []string {
+	return []string{strconv.Itoa(p.ID), p.Name, moneyFromUSD(p.USDPerUnit).String(), p.Unit}
+}
 
-	f, err := os.Open("customers.csv")
-	if err != nil {
-		log.Fatal(err)
+func (p Product) ToProto() proto.Message {
+	return &productpb.Product{Id: int32(p.ID), Name: p.Name, UsdPerUnit: p.USDPerUnit, Unit: p.Unit}
+}
+
+// fromCatalog and toCatalog convert between the store's products and the
+// ones this service renders.
+func fromCatalog(ps []catalog.Product) []Product {
+	result := make([]Product, len(ps))
+	for i, p := range ps {
+		result[i] = Product(p)
 	}
-	customers, err := readCustomers(f)
-	f.Close()
-	if err != nil {
-		log.Fatal(err)
+	return result
+}
+
+func toCatalog(ps []Product) []catalog.Product {
+	result := make([]catalog.Product, len(ps))
+	for i, p := range ps {
+		result[i] = catalog.Product(p)
 	}
+	return result
+}
 
-	mux := http.NewServeMux()
+var productList = listSpec[Product]{
+	fields: map[string]func(Product) any{
+		"id":         func(p Product) any { return p.ID },
+		"name":       func(p Product) any { return p.Name },
+		"usdPerUnit": func(p Product) any { return p.USDPerUnit },
+		"unit":       func(p Product) any { return p.Unit },
+	},
+	filters: map[string]func(string) (func(Product) bool, error){
+		"name":     equalsFold(func(p Product) string { return p.Name }),
+		"unit":     equalsFold(func(p Product) string { return p.Unit }),
+		"minPrice": priceBound(func(p Product) float64 { return p.USDPerUnit }, false),
+		"maxPrice": priceBound(func(p Product) float64 { return p.USDPerUnit }, true),
+	},
+	id: func(p Product) int { return p.ID },
+}
+
+// productsPolicy limits product lookups per client. It is generous because
+// the cart service looks up several products for every cart it validates.
+var productsPolicy = ratelimit.Policy{Name: "products", Limit: 600, Window: time.Minute}
+
+// productCacheControl applies to single products, which change rarely
+// and are the same for every client.
+const productCacheControl = "public, max-age=60"
 
-	mux.HandleFunc("/customers", func(w http.ResponseWriter, r *http.Request) {
+func createProductService(addr string, store *catalog.Store, checks *health.Checker, tracer *trace.Tracer, limiter *ratelimit.Limiter) *http.Server {
+	reg := newMetrics()
 
-		data, err := json.Marshal(customers)
+	router := middleware.NewRouter(trace.Middleware(tracer), middleware.Logging(loggingConfig()), metrics.Middleware(reg))
+
+	router.Handle("/products", problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		page, err := productList.page(w, r, fromCatalog(store.List()))
 		if err != nil {
-			log.Print(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			return pageError(err)
 		}
-		w.Header().Add("content-type", "application/json")
-		w.Write(data)
-
-	})
+		render.Respond(w, r, http.StatusOK, page)
+		return nil
+	}), limiter.Middleware(productsPolicy))
 
-	pattern := regexp.MustCompile(`^\/customers\/(\d+?)$`)
-	mux.HandleFunc("/customers/", func(w http.ResponseWriter, r *http.Request) {
+	pattern := regexp.MustCompile(`^\/products\/(\d+?)$`)
+	router.Handle("/products/", problem.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		matches := pattern.FindStringSubmatch(r.URL.Path)
 		if len(matches) == 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			return problem.NotFound(r)
 		}
 
 		id, err := strconv.Atoi(matches[1])
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			return problem.BadRequest("invalid product ID %q", matches[1])
 		}
 
-		for _, c := range customers {
-			if id == c.ID {
-				data, err := json.Marshal(c)
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				if r.Method == http.MethodGet {
-					w.Header().Add("Content-Type", "application/json")
-					w.Write(data)
-				}
-				return
-			}
+		p, err := store.Get(id)
+		if errors.Is(err, catalog.ErrNotFound) {
+			return problem.NotFound(r)
+		}
+		if err != nil {
+			return err
+		}
+		etag, err := conditional.ETag(r, Product(p))
+		if err != nil {
+			return err
+		}
+		if conditional.NotModified(w, r, etag) {
+			return nil
 		}
-		w.WriteHeader(http.StatusNotFound)
+		render.Respond(w, r, http.StatusOK, Product(p))
+		return nil
+	}), limiter.Middleware(productsPolicy), conditional.CacheControl(productCacheControl))
 
-	})
+	router.Handle("/metrics", reg.Handler())
+	router.Handle("/healthz", checks.LivenessHandler())
+	router.Handle("/readyz", checks.ReadinessHandler())
+	router.Handle("/", problem.NotFoundHandler())
 
 	s := http.Server{
-		Addr:    ":3000",
-		Handler: mux,
+		Addr:    addr,
+		Handler: router,
 	}
 
 	return &s
-
-}
-
-func readCustomers(r io.Reader) ([]Customer, error) {
-	customers := make([]Customer, 0)
-	csvReader := csv.NewReader(r)
-	csvReader.Read() // throw away header
-	for {
-		fields, err := csvReader.Read()
-		if err == io.EOF {
-			return customers, nil
-		}
-		if err != nil {
-			return nil, err
-		}
-		var c Customer
-		id, err := strconv.Atoi(fields[0])
-		if err != nil {
-			continue
-		}
-		c.ID = id
-		c.FirstName = fields[1]
-		c.LastName = fields[2]
-		c.Address = fields[3]
-		customers = append(customers, c)
-	}
 }
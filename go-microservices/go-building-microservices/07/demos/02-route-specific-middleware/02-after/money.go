@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of US dollars held as a whole number of cents, so that
+// adding up line items never picks up floating point rounding errors.
+type Money int64
+
+// moneyFromUSD converts a price as published by the product service. The
+// float is rounded to the nearest cent once, at the boundary.
+func moneyFromUSD(usd float64) Money {
+	return Money(math.Round(usd * 100))
+}
+
+// Times returns the price of n units.
+func (m Money) Times(n int) Money {
+	return m * Money(n)
+}
+
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+
+// MarshalText writes the amount as a decimal string such as "12.99", which
+// clients can parse without going through a float. JSON and XML both use
+// it, so every representation agrees.
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalText(data []byte) error {
+	v, err := parseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// parseMoney reads an amount written the way String writes it: an
+// optional minus sign, whole dollars and up to two decimal places.
+func parseMoney(s string) (Money, error) {
+	unsigned, neg := strings.CutPrefix(s, "-")
+	whole, frac, hasFrac := strings.Cut(unsigned, ".")
+	switch {
+	case !isDigits(whole) || (hasFrac && !isDigits(frac)):
+		return 0, fmt.Errorf("invalid amount %q", s)
+	case len(frac) > 2:
+		return 0, fmt.Errorf("invalid amount %q: more than two decimal places", s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	cents, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: out of range", s)
+	}
+	if neg {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// isDigits reports whether s is one or more ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
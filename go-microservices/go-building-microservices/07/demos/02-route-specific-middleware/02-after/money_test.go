@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	valid := map[string]Money{
+		"12.99":                1299,
+		"12.9":                 1290,
+		"12":                   1200,
+		"0.05":                 5,
+		"-1.50":                -150,
+		"-0":                   0,
+		"92233720368547758.07": 9223372036854775807,
+	}
+	for s, want := range valid {
+		got, err := parseMoney(s)
+		if err != nil || got != want {
+			t.Errorf("parseMoney(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	invalid := []string{
+		"", "-", ".", "1.", ".5", "1.-5", "1.+5", "+1", "--1", "-+1", "1.234",
+		"1,50", " 1", "1e2", "0x10", "1.5.0",
+		"92233720368547758.08", "-92233720368547758.09", "99999999999999999999",
+	}
+	for _, s := range invalid {
+		if got, err := parseMoney(s); err == nil {
+			t.Errorf("parseMoney(%q) = %v, want an error", s, got)
+		}
+	}
+}
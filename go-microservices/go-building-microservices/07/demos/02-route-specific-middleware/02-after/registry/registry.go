@@ -0,0 +1,155 @@
+// Package registry maps service names such as "customer" to the base URLs
+// of their running instances, so callers never hard-code host and port.
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrNoInstances = errors.New("no instances registered")
+
+// Resolver returns the base URL of one instance of a service, for example
+// "http://localhost:3000".
+type Resolver interface {
+	Resolve(name string) (string, error)
+}
+
+// Static is a fixed set of service instances, keyed by service name.
+type Static map[string][]string
+
+// LoadFile reads a JSON object of the form
+//
+//	{"customer": ["http://localhost:3000"], "product": ["http://localhost:4000"]}
+//
+// A missing file is not an error and yields an empty config.
+func LoadFile(path string) (Static, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Static{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := Static{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("reading %v: %w", path, err)
+	}
+	return s, nil
+}
+
+// FromEnv reads SERVICE_<NAME>_URLS variables, each holding a
+// comma-separated list of base URLs. SERVICE_CUSTOMER_URLS configures the
+// "customer" service.
+func FromEnv(environ []string) Static {
+	s := Static{}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, "SERVICE_") || !strings.HasSuffix(key, "_URLS") {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(key, "SERVICE_"), "_URLS"))
+		for _, u := range strings.Split(value, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				s[name] = append(s[name], u)
+			}
+		}
+	}
+	return s
+}
+
+// Merge returns a config holding the instances of both s and other.
+func (s Static) Merge(other Static) Static {
+	result := Static{}
+	for _, src := range []Static{s, other} {
+		for name, urls := range src {
+			result[name] = append(result[name], urls...)
+		}
+	}
+	return result
+}
+
+func (s Static) Resolve(name string) (string, error) {
+	urls := s[name]
+	if len(urls) == 0 {
+		return "", fmt.Errorf("%v: %w", name, ErrNoInstances)
+	}
+	return urls[0], nil
+}
+
+// Local is an in-process registry that services add themselves to when
+// they start. Resolve hands out instances round-robin.
+type Local struct {
+	mu        sync.Mutex
+	instances map[string][]string
+	next      map[string]int
+}
+
+// NewLocal creates a registry pre-populated with the given static
+// instances, which may be nil.
+func NewLocal(seed Static) *Local {
+	l := &Local{
+		instances: make(map[string][]string),
+		next:      make(map[string]int),
+	}
+	for name, urls := range seed {
+		for _, u := range urls {
+			l.Register(name, u)
+		}
+	}
+	return l
+}
+
+// Register adds an instance. Registering the same URL twice is a no-op.
+func (l *Local) Register(name, url string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, u := range l.instances[name] {
+		if u == url {
+			return
+		}
+	}
+	l.instances[name] = append(l.instances[name], url)
+}
+
+func (l *Local) Deregister(name, url string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	urls := l.instances[name]
+	for i, u := range urls {
+		if u == url {
+			l.instances[name] = append(urls[:i:i], urls[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *Local) Resolve(name string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	urls := l.instances[name]
+	if len(urls) == 0 {
+		return "", fmt.Errorf("%v: %w", name, ErrNoInstances)
+	}
+	i := l.next[name] % len(urls)
+	l.next[name] = i + 1
+	return urls[i], nil
+}
+
+// URLFor turns a listen address such as ":3000" into the base URL other
+// processes on the same machine use to reach it.
+func URLFor(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
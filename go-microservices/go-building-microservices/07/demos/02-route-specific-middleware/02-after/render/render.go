@@ -0,0 +1,274 @@
+// Package render writes response bodies in the representation the client
+// asked for in its Accept header: JSON, XML, CSV or protobuf.
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+
+	"shared/problem"
+)
+
+const (
+	JSON     = "application/json"
+	XML      = "application/xml"
+	CSV      = "text/csv"
+	Protobuf = "application/x-protobuf"
+)
+
+// CSVRecord is implemented by values that can be written as a CSV row.
+// A slice of them is written as a header followed by one row each.
+type CSVRecord interface {
+	CSVHeader() []string
+	CSVRecord() []string
+}
+
+// ProtoConvertible is implemented by values with a protobuf form. A
+// single value is written as one message; a slice is written as a stream
+// of length-delimited messages.
+type ProtoConvertible interface {
+	ToProto() proto.Message
+}
+
+// Respond encodes v in the best representation the request accepts and
+// writes it with status. When v cannot be represented in any accepted
+// type it answers 406 Not Acceptable with a problem listing the types on
+// offer.
+//
+// The body is encoded before anything is written, so an encoding error
+// still produces a clean 500.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Add("Vary", "Accept")
+
+	offered := Offers(v)
+	mediaType, ok := Negotiate(r.Header.Get("Accept"), offered)
+	if !ok {
+		problem.Write(w, r, notAcceptable(offered))
+		return
+	}
+
+	data, err := Encode(mediaType, v)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(mediaType))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// Acceptable returns the 406 problem Respond would write if v cannot be
+// represented in any type the request accepts, and nil otherwise.
+// Handlers that change state call it before making the change, so a
+// request that can never be answered has no effect. v only needs to have
+// the type of the eventual response; a zero value will do.
+func Acceptable(r *http.Request, v any) error {
+	offered := Offers(v)
+	if _, ok := Negotiate(r.Header.Get("Accept"), offered); !ok {
+		return notAcceptable(offered)
+	}
+	return nil
+}
+
+func notAcceptable(offered []string) *problem.Error {
+	err := problem.New(http.StatusNotAcceptable, "acceptable representations: %v", strings.Join(offered, ", "))
+	return err.With("acceptable", offered)
+}
+
+func contentType(mediaType string) string {
+	switch mediaType {
+	case JSON, XML, CSV:
+		return mediaType + "; charset=utf-8"
+	}
+	return mediaType
+}
+
+// Offers lists the media types v can be encoded as, in order of
+// preference.
+func Offers(v any) []string {
+	offered := []string{JSON, XML}
+	elem := elemType(v)
+	if elem.Implements(reflect.TypeOf((*CSVRecord)(nil)).Elem()) {
+		offered = append(offered, CSV)
+	}
+	if elem.Implements(reflect.TypeOf((*ProtoConvertible)(nil)).Elem()) {
+		offered = append(offered, Protobuf)
+	}
+	return offered
+}
+
+// elemType is the element type of a slice, or the type of v itself.
+func elemType(v any) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return reflect.TypeOf(struct{}{})
+	}
+	if t.Kind() == reflect.Slice {
+		return t.Elem()
+	}
+	return t
+}
+
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+	order        int
+}
+
+// Negotiate picks the offered type the Accept header prefers. An empty
+// header accepts anything, in which case the first offer wins.
+func Negotiate(accept string, offered []string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offered[0], true
+	}
+
+	var ranges []mediaRange
+	for i, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, _ := strings.Cut(mt, "/")
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q, order: i})
+	}
+	// Highest quality first; among equals, more specific ranges win, then
+	// the order the client listed them in.
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return specificity(ranges[i]) > specificity(ranges[j])
+	})
+
+	for _, mr := range ranges {
+		if mr.q <= 0 {
+			continue
+		}
+		for _, o := range offered {
+			typ, subtype, _ := strings.Cut(o, "/")
+			if (mr.typ == "*" || mr.typ == typ) && (mr.subtype == "*" || mr.subtype == subtype) {
+				if excluded(ranges, o) {
+					continue
+				}
+				return o, true
+			}
+		}
+	}
+	return "", false
+}
+
+func specificity(mr mediaRange) int {
+	switch {
+	case mr.typ == "*":
+		return 0
+	case mr.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+// excluded reports whether the client explicitly refused offered with q=0.
+func excluded(ranges []mediaRange, offered string) bool {
+	for _, mr := range ranges {
+		if mr.q == 0 && mr.typ+"/"+mr.subtype == offered {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode writes v in the given media type.
+func Encode(mediaType string, v any) ([]byte, error) {
+	switch mediaType {
+	case JSON:
+		return json.Marshal(v)
+	case XML:
+		return encodeXML(v)
+	case CSV:
+		return encodeCSV(v)
+	case Protobuf:
+		return encodeProto(v)
+	}
+	return nil, fmt.Errorf("render: unsupported media type %q", mediaType)
+}
+
+// list wraps a slice so it has a single root element.
+type list struct {
+	XMLName xml.Name `xml:"list"`
+	Items   any
+}
+
+func encodeXML(v any) ([]byte, error) {
+	if reflect.TypeOf(v) != nil && reflect.TypeOf(v).Kind() == reflect.Slice {
+		v = list{Items: v}
+	}
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func encodeCSV(v any) ([]byte, error) {
+	var records []CSVRecord
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			records = append(records, rv.Index(i).Interface().(CSVRecord))
+		}
+	} else {
+		records = append(records, v.(CSVRecord))
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	var zero CSVRecord
+	if len(records) > 0 {
+		zero = records[0]
+	} else {
+		zero = reflect.Zero(elemType(v)).Interface().(CSVRecord)
+	}
+	if err := w.Write(zero.CSVHeader()); err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		if err := w.Write(rec.CSVRecord()); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func encodeProto(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return proto.Marshal(v.(ProtoConvertible).ToProto())
+	}
+	var buf bytes.Buffer
+	for i := 0; i < rv.Len(); i++ {
+		m := rv.Index(i).Interface().(ProtoConvertible).ToProto()
+		if _, err := protodelim.MarshalTo(&buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
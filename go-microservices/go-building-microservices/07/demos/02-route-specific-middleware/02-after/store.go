@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+var ErrCartNotFound = errors.New("cart not found")
+
+// CartStore holds the shopping carts. Implementations must be safe for
+// concurrent use, since every request to the cart service runs in its own
+// goroutine.
+type CartStore interface {
+	List() ([]Cart, error)
+	Get(id int) (Cart, error)
+	// Create assigns the next free ID to c and stores it.
+	Create(c Cart) (Cart, error)
+	// Update loads the cart with the given ID, hands it to fn and stores
+	// the result. The cart is locked for the duration of fn, so
+	// read-modify-write sequences such as adding an item cannot race.
+	// If fn returns an error nothing is stored.
+	Update(id int, fn func(c *Cart) error) (Cart, error)
+	Delete(id int) error
+}
+
+// memoryCartStore keeps carts in a map guarded by a RWMutex. Nothing
+// survives a restart.
+type memoryCartStore struct {
+	mu     sync.RWMutex
+	carts  map[int]Cart
+	nextID int
+}
+
+func newMemoryCartStore() *memoryCartStore {
+	return &memoryCartStore{
+		carts:  make(map[int]Cart),
+		nextID: 1,
+	}
+}
+
+func (s *memoryCartStore) List() ([]Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list(), nil
+}
+
+func (s *memoryCartStore) list() []Cart {
+	result := make([]Cart, 0, len(s.carts))
+	for _, c := range s.carts {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+func (s *memoryCartStore) Get(id int) (Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.carts[id]
+	if !ok {
+		return Cart{}, ErrCartNotFound
+	}
+	return c, nil
+}
+
+func (s *memoryCartStore) Create(c Cart) (Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.ID = s.nextID
+	s.nextID++
+	s.carts[c.ID] = c
+	return c, nil
+}
+
+func (s *memoryCartStore) Update(id int, fn func(c *Cart) error) (Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.modify(id, fn)
+	if err != nil {
+		return Cart{}, err
+	}
+	s.carts[id] = c
+	return c, nil
+}
+
+// modify runs fn against a copy of the stored cart. The caller must hold
+// the write lock.
+func (s *memoryCartStore) modify(id int, fn func(c *Cart) error) (Cart, error) {
+	c, ok := s.carts[id]
+	if !ok {
+		return Cart{}, ErrCartNotFound
+	}
+	c.Items = append([]CartItem(nil), c.Items...)
+	if err := fn(&c); err != nil {
+		return Cart{}, err
+	}
+	c.ID = id
+	return c, nil
+}
+
+func (s *memoryCartStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.carts[id]; !ok {
+		return ErrCartNotFound
+	}
+	delete(s.carts, id)
+	return nil
+}
+
+// apply replays a single log entry. The caller must hold the write lock.
+func (s *memoryCartStore) apply(e cartLogEntry) {
+	switch e.Op {
+	case opPut:
+		s.carts[e.Cart.ID] = e.Cart
+		if e.Cart.ID >= s.nextID {
+			s.nextID = e.Cart.ID + 1
+		}
+	case opDelete:
+		delete(s.carts, e.Cart.ID)
+	}
+}
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+type cartLogEntry struct {
+	Op   string `json:"op"`
+	Cart Cart   `json:"cart"`
+}
+
+type cartSnapshot struct {
+	NextID int    `json:"nextId"`
+	Carts  []Cart `json:"carts"`
+}
+
+// fileCartStore is a durable CartStore. Every change is appended to a JSON
+// log and fsynced before the call returns. Once the log holds
+// snapshotEvery entries the whole state is written to a snapshot file and
+// the log is truncated, so startup only has to replay a short tail.
+type fileCartStore struct {
+	mem           *memoryCartStore
+	dir           string
+	log           *os.File
+	entries       int
+	snapshotEvery int
+}
+
+const defaultSnapshotEvery = 100
+
+func openFileCartStore(dir string) (*fileCartStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &fileCartStore{
+		mem:           newMemoryCartStore(),
+		dir:           dir,
+		snapshotEvery: defaultSnapshotEvery,
+	}
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := s.replayLog(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.logPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.log = f
+	return s, nil
+}
+
+func (s *fileCartStore) snapshotPath() string { return filepath.Join(s.dir, "carts.snapshot.json") }
+func (s *fileCartStore) logPath() string      { return filepath.Join(s.dir, "carts.log") }
+
+func (s *fileCartStore) loadSnapshot() error {
+	data, err := os.ReadFile(s.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snap cartSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("reading cart snapshot: %w", err)
+	}
+	for _, c := range snap.Carts {
+		s.mem.carts[c.ID] = c
+	}
+	if snap.NextID > s.mem.nextID {
+		s.mem.nextID = snap.NextID
+	}
+	return nil
+}
+
+// replayLog applies the log on top of the snapshot. A torn final write
+// from a crash is expected and cut off, so the next append starts on a
+// fresh line; anything wrong earlier in the log means it is corrupt.
+func (s *fileCartStore) replayLog() error {
+	f, err := os.Open(s.logPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var good int64
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Whatever follows the last newline was never acknowledged.
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var e cartLogEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("cart log line %d: %w", line, err)
+		}
+		s.mem.apply(e)
+		s.entries++
+		good += int64(len(data))
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > good {
+		log.Printf("Discarding %d bytes of torn cart log entry", info.Size()-good)
+		return os.Truncate(s.logPath(), good)
+	}
+	return nil
+}
+
+func (s *fileCartStore) List() ([]Cart, error) {
+	return s.mem.List()
+}
+
+func (s *fileCartStore) Get(id int) (Cart, error) {
+	return s.mem.Get(id)
+}
+
+func (s *fileCartStore) Create(c Cart) (Cart, error) {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	c.ID = s.mem.nextID
+	if err := s.append(cartLogEntry{Op: opPut, Cart: c}); err != nil {
+		return Cart{}, err
+	}
+	s.mem.apply(cartLogEntry{Op: opPut, Cart: c})
+	s.maybeSnapshot()
+	return c, nil
+}
+
+func (s *fileCartStore) Update(id int, fn func(c *Cart) error) (Cart, error) {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	c, err := s.mem.modify(id, fn)
+	if err != nil {
+		return Cart{}, err
+	}
+	if err := s.append(cartLogEntry{Op: opPut, Cart: c}); err != nil {
+		return Cart{}, err
+	}
+	s.mem.apply(cartLogEntry{Op: opPut, Cart: c})
+	s.maybeSnapshot()
+	return c, nil
+}
+
+func (s *fileCartStore) Delete(id int) error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	if _, ok := s.mem.carts[id]; !ok {
+		return ErrCartNotFound
+	}
+	e := cartLogEntry{Op: opDelete, Cart: Cart{ID: id}}
+	if err := s.append(e); err != nil {
+		return err
+	}
+	s.mem.apply(e)
+	s.maybeSnapshot()
+	return nil
+}
+
+// append writes e to the log. The caller must hold the write lock.
+func (s *fileCartStore) append(e cartLogEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := s.log.Write(data); err != nil {
+		return err
+	}
+	if err := s.log.Sync(); err != nil {
+		return err
+	}
+	s.entries++
+	return nil
+}
+
+// maybeSnapshot compacts the log once it has grown past snapshotEvery
+// entries. The change that triggered it is already durable in the log, so
+// a failed compaction is only logged and retried after the next write.
+// The caller must hold the write lock.
+func (s *fileCartStore) maybeSnapshot() {
+	if s.entries < s.snapshotEvery {
+		return
+	}
+	if err := s.snapshot(); err != nil {
+		log.Printf("Compacting cart log: %v", err)
+	}
+}
+
+func (s *fileCartStore) snapshot() error {
+	data, err := json.Marshal(cartSnapshot{NextID: s.mem.nextID, Carts: s.mem.list()})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.snapshotPath(), data); err != nil {
+		return err
+	}
+	if err := s.log.Truncate(0); err != nil {
+		return err
+	}
+	s.entries = 0
+	return nil
+}
+
+func (s *fileCartStore) Close() error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	return s.log.Close()
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never see a half-written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
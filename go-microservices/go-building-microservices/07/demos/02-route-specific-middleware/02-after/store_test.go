@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryCartStore(t *testing.T) {
+	s := newMemoryCartStore()
+
+	a, _ := s.Create(Cart{CustomerID: 1})
+	b, _ := s.Create(Cart{CustomerID: 2})
+	if a.ID != 1 || b.ID != 2 {
+		t.Fatalf("IDs = %d, %d, want 1, 2", a.ID, b.ID)
+	}
+
+	_, err := s.Update(a.ID, func(c *Cart) error {
+		return c.addItem(CartItem{ProductID: 3, Quantity: 2})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	boom := errors.New("boom")
+	if _, err := s.Update(a.ID, func(c *Cart) error {
+		c.Items[0].Quantity = 99
+		return boom
+	}); err != boom {
+		t.Fatalf("Update error = %v, want %v", err, boom)
+	}
+	got, _ := s.Get(a.ID)
+	if want := []CartItem{{ProductID: 3, Quantity: 2}}; !reflect.DeepEqual(got.Items, want) {
+		t.Errorf("items after failed update = %v, want %v", got.Items, want)
+	}
+
+	if err := s.Delete(b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(b.ID); err != ErrCartNotFound {
+		t.Errorf("Get deleted cart error = %v, want %v", err, ErrCartNotFound)
+	}
+	if err := s.Delete(b.ID); err != ErrCartNotFound {
+		t.Errorf("Delete deleted cart error = %v, want %v", err, ErrCartNotFound)
+	}
+	if list, _ := s.List(); len(list) != 1 || list[0].ID != a.ID {
+		t.Errorf("List = %v, want only cart %d", list, a.ID)
+	}
+}
+
+func openTestStore(t *testing.T, dir string) *fileCartStore {
+	t.Helper()
+	s, err := openFileCartStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestFileCartStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	a, _ := s.Create(Cart{CustomerID: 1})
+	b, _ := s.Create(Cart{CustomerID: 2})
+	s.Update(a.ID, func(c *Cart) error { return c.addItem(CartItem{ProductID: 1, Quantity: 1}) })
+	s.Delete(b.ID)
+	s.Close()
+
+	s = openTestStore(t, dir)
+	list, _ := s.List()
+	want := []Cart{{ID: 1, CustomerID: 1, Items: []CartItem{{ProductID: 1, Quantity: 1}}}}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("after reopen List = %v, want %v", list, want)
+	}
+	// IDs are never reused, even for deleted carts.
+	if c, _ := s.Create(Cart{}); c.ID != 3 {
+		t.Errorf("next ID = %d, want 3", c.ID)
+	}
+}
+
+func TestFileCartStoreTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	s.Create(Cart{CustomerID: 1})
+	s.Close()
+
+	logPath := filepath.Join(dir, "carts.log")
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"put","cart":{"id":2,"cust`)
+	f.Close()
+
+	s = openTestStore(t, dir)
+	if _, err := s.Create(Cart{CustomerID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// The new entry must not have been glued onto the torn one.
+	s = openTestStore(t, dir)
+	if list, _ := s.List(); len(list) != 2 || list[1].CustomerID != 2 {
+		t.Errorf("List = %v, want carts for customers 1 and 2", list)
+	}
+}
+
+func TestFileCartStoreCorruptLog(t *testing.T) {
+	dir := t.TempDir()
+	data := "not json\n" + `{"op":"put","cart":{"id":1}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "carts.log"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openFileCartStore(dir); err == nil {
+		t.Error("opened a store whose log is corrupt before its last line")
+	}
+}
+
+func TestFileCartStoreSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	s.snapshotEvery = 3
+	for i := 1; i <= 4; i++ {
+		s.Create(Cart{CustomerID: i})
+	}
+	if s.entries != 1 {
+		t.Errorf("entries after compaction = %d, want 1", s.entries)
+	}
+	s.Close()
+
+	s = openTestStore(t, dir)
+	if list, _ := s.List(); len(list) != 4 {
+		t.Errorf("after reopen List has %d carts, want 4", len(list))
+	}
+}
+
+func TestFileCartStoreSnapshotFailure(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestStore(t, dir)
+	s.snapshotEvery = 1
+
+	// A non-empty directory where the snapshot belongs makes every
+	// compaction fail.
+	if err := os.MkdirAll(filepath.Join(s.snapshotPath(), "blocker"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Create(Cart{CustomerID: 1})
+	if err != nil {
+		t.Fatalf("Create with failing compaction: %v", err)
+	}
+	if _, err := s.Get(c.ID); err != nil {
+		t.Errorf("Get after failed compaction: %v", err)
+	}
+}
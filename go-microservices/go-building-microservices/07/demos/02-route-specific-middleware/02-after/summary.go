@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"demo/render"
+	"shared/problem"
+)
+
+type SummaryLine struct {
+	ProductID int    `json:"productId" xml:"productId"`
+	Name      string `json:"name" xml:"name"`
+	Unit      string `json:"unit" xml:"unit"`
+	Quantity  int    `json:"quantity" xml:"quantity"`
+	UnitPrice Money  `json:"unitPrice" xml:"unitPrice"`
+	Subtotal  Money  `json:"subtotal" xml:"subtotal"`
+}
+
+type CartSummary struct {
+	CartID     int           `json:"cartId" xml:"cartId"`
+	CustomerID int           `json:"customerId" xml:"customerId"`
+	Lines      []SummaryLine `json:"lines" xml:"line"`
+	Total      Money         `json:"total" xml:"total"`
+	Currency   string        `json:"currency" xml:"currency"`
+}
+
+// unknownProductsError lists the product IDs the product service did not
+// recognise.
+type unknownProductsError struct {
+	IDs []int
+}
+
+func (e *unknownProductsError) Error() string {
+	return fmt.Sprintf("unknown product IDs: %v", e.IDs)
+}
+
+var cartSummaryPattern = regexp.MustCompile(`^\/carts\/(\d+?)\/summary$`)
+
+// summarizeCart prices every item in the cart against the product service.
+func summarizeCart(ctx context.Context, up *upstreams, c Cart) (CartSummary, error) {
+	ids := make([]int, 0, len(c.Items))
+	for _, item := range c.Items {
+		ids = append(ids, item.ProductID)
+	}
+	products, unknown, err := up.lookupProducts(ctx, ids)
+	if err != nil {
+		return CartSummary{}, err
+	}
+	if len(unknown) > 0 {
+		return CartSummary{}, &unknownProductsError{IDs: unknown}
+	}
+
+	summary := CartSummary{
+		CartID:     c.ID,
+		CustomerID: c.CustomerID,
+		Lines:      make([]SummaryLine, 0, len(c.Items)),
+		Currency:   "USD",
+	}
+	for _, item := range c.Items {
+		p := products[item.ProductID]
+		price := moneyFromUSD(p.USDPerUnit)
+		line := SummaryLine{
+			ProductID: p.ID,
+			Name:      p.Name,
+			Unit:      p.Unit,
+			Quantity:  item.Quantity,
+			UnitPrice: price,
+			Subtotal:  price.Times(item.Quantity),
+		}
+		summary.Lines = append(summary.Lines, line)
+		summary.Total += line.Subtotal
+	}
+	return summary, nil
+}
+
+func cartSummaryHandler(store CartStore, up *upstreams) problem.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method != http.MethodGet {
+			return problem.MethodNotAllowed(r)
+		}
+		matches := cartSummaryPattern.FindStringSubmatch(r.URL.Path)
+		id, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return problem.BadRequest("invalid cart ID %q", matches[1])
+		}
+
+		c, err := store.Get(id)
+		if err != nil {
+			return storeError(err)
+		}
+
+		summary, err := summarizeCart(r.Context(), up, c)
+		if err != nil {
+			return lookupError(w, err)
+		}
+		render.Respond(w, r, http.StatusOK, summary)
+		return nil
+	}
+}
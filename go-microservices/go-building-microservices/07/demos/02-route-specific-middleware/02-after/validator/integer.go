@@ -0,0 +1,29 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// IntegerValidator accepts whole numbers between Min and Max inclusive.
+type IntegerValidator struct {
+	Min int
+	Max int
+}
+
+func NewIntegerValidator(min, max int) *IntegerValidator {
+	return &IntegerValidator{Min: min, Max: max}
+}
+
+func (s *IntegerValidator) Validate(value string) (int, error) {
+	num, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid input")
+	}
+	if num < s.Min || num > s.Max {
+		return 0, fmt.Errorf("input out of range")
+	}
+
+	return num, nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor rejects calls without a valid bearer token with
+// UNAUTHENTICATED, and puts the caller's claims in the context of the
+// rest. Methods in public, such as health checks, need no token.
+func UnaryServerInterceptor(v Verifier, public map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor checks streams the way UnaryServerInterceptor
+// checks unary calls, once when the stream opens.
+func StreamServerInterceptor(v Verifier, public map[string]bool) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		ctx, err := authenticate(ss.Context(), v)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, v Verifier) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ctx, status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+	}
+	claims, err := v.Verify(token)
+	if err != nil {
+		// Why a token was refused helps an attacker more than a client,
+		// so the reason is only logged.
+		log.Printf("Rejected bearer token: %v", err)
+		return ctx, status.Error(codes.Unauthenticated, ErrInvalidToken.Error())
+	}
+	return WithClaims(ctx, claims), nil
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// BearerToken sends Token as the authorization of every call. Tokens are
+// only sent over TLS unless AllowInsecure is set, which is for local
+// development.
+type BearerToken struct {
+	Token         string
+	AllowInsecure bool
+}
+
+func (t BearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.Token}, nil
+}
+
+func (t BearerToken) RequireTransportSecurity() bool {
+	return !t.AllowInsecure
+}
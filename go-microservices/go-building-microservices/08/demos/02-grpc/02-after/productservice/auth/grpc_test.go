@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testMethod   = "/productService.Product/GetProduct"
+	publicMethod = "/grpc.health.v1.Health/Check"
+)
+
+var testJWT = JWT{Key: []byte("test key"), Issuer: "productservice"}
+
+func testVerifier() Verifier {
+	return Any{testJWT, Tokens{"service-token": "cart"}}
+}
+
+// call runs the unary interceptor with authorization as the request's
+// authorization metadata, and returns the caller the handler saw.
+func call(t *testing.T, method, authorization string) (string, error) {
+	t.Helper()
+	ctx := context.Background()
+	if authorization != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+	}
+	var user string
+	interceptor := UnaryServerInterceptor(testVerifier(), map[string]bool{publicMethod: true})
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+		if claims, ok := FromContext(ctx); ok {
+			user = claims.Username
+		}
+		return nil, nil
+	})
+	return user, err
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestUnaryServerInterceptorAccepts(t *testing.T) {
+	token, err := testJWT.Sign("alice", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		method, authorization, user string
+	}{
+		"user token":         {testMethod, "Bearer " + token, "alice"},
+		"service token":      {testMethod, "bearer service-token", "cart"},
+		"public without one": {publicMethod, "", ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			user, err := call(t, tt.method, tt.authorization)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user != tt.user {
+				t.Errorf("handler saw user %q, want %q", user, tt.user)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorRefuses(t *testing.T) {
+	now := time.Now()
+	valid := jwt.RegisteredClaims{Issuer: "productservice", ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+	otherIssuer := valid
+	otherIssuer.Issuer = "elsewhere"
+	noExpiry := valid
+	noExpiry.ExpiresAt = nil
+
+	tests := map[string]struct {
+		authorization string
+		message       string
+	}{
+		"missing":      {"", "missing bearer token"},
+		"basic auth":   {"Basic YWxpY2U6cHc=", "authorization must be a bearer token"},
+		"unknown":      {"Bearer nonsense", "invalid token"},
+		"expired":      {"Bearer " + sign(t, jwt.SigningMethodHS256, testJWT.Key, Claims{"alice", expired}), "invalid token"},
+		"no expiry":    {"Bearer " + sign(t, jwt.SigningMethodHS256, testJWT.Key, Claims{"alice", noExpiry}), "invalid token"},
+		"other issuer": {"Bearer " + sign(t, jwt.SigningMethodHS256, testJWT.Key, Claims{"alice", otherIssuer}), "invalid token"},
+		"wrong key":    {"Bearer " + sign(t, jwt.SigningMethodHS256, []byte("other key"), Claims{"alice", valid}), "invalid token"},
+		"alg none":     {"Bearer " + sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, Claims{"alice", valid}), "invalid token"},
+		"HS512":        {"Bearer " + sign(t, jwt.SigningMethodHS512, testJWT.Key, Claims{"alice", valid}), "invalid token"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := call(t, testMethod, tt.authorization)
+			st := status.Convert(err)
+			if st.Code() != codes.Unauthenticated || st.Message() != tt.message {
+				t.Errorf("error = %v, want Unauthenticated %q", err, tt.message)
+			}
+		})
+	}
+}
+
+// testStream is a server stream with a fixed context.
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor(testVerifier(), nil)
+	info := &grpc.StreamServerInfo{FullMethod: "/productService.Product/WatchProducts"}
+
+	var user string
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer service-token"))
+	err := interceptor(nil, &testStream{ctx: ctx}, info, func(srv any, ss grpc.ServerStream) error {
+		if claims, ok := FromContext(ss.Context()); ok {
+			user = claims.Username
+		}
+		return nil
+	})
+	if err != nil || user != "cart" {
+		t.Errorf("handler saw user %q and error %v, want cart", user, err)
+	}
+
+	called := false
+	err = interceptor(nil, &testStream{ctx: context.Background()}, info, func(srv any, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+	if status.Code(err) != codes.Unauthenticated || called {
+		t.Errorf("stream without a token: error %v, handler called %v", err, called)
+	}
+}
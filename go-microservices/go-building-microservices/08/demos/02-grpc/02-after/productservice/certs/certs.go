@@ -0,0 +1,197 @@
+// Package certs loads TLS certificates from PEM files and picks up new
+// ones when the files are rotated, without restarting the server or
+// redialling the client.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ReloadInterval is how often the files are checked for changes. Checks
+// happen during handshakes, so an idle server does no work.
+var ReloadInterval = 10 * time.Second
+
+// reloadable holds a value loaded from files and reloads it when any of
+// their modification times change. If a reload fails, for example because
+// the certificate has been replaced but the key not yet, the previous
+// value is kept and the next check tries again.
+type reloadable[T any] struct {
+	files []string
+	load  func() (T, error)
+
+	mu      sync.Mutex
+	value   T
+	modTime time.Time
+	checked time.Time
+}
+
+func newReloadable[T any](load func() (T, error), files ...string) (*reloadable[T], error) {
+	r := &reloadable[T]{files: files, load: load}
+	modTime, err := latestModTime(files)
+	if err != nil {
+		return nil, err
+	}
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	r.value, r.modTime, r.checked = value, modTime, time.Now()
+	return r, nil
+}
+
+func (r *reloadable[T]) get() T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < ReloadInterval {
+		return r.value
+	}
+	r.checked = time.Now()
+
+	modTime, err := latestModTime(r.files)
+	if err != nil || modTime.Equal(r.modTime) {
+		return r.value
+	}
+	value, err := r.load()
+	if err != nil {
+		log.Printf("certs: keeping the current certificate, reload failed: %v", err)
+		return r.value
+	}
+	log.Printf("certs: reloaded %v", r.files)
+	r.value, r.modTime = value, modTime
+	return r.value
+}
+
+func latestModTime(files []string) (time.Time, error) {
+	var latest time.Time
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// KeyPair is a certificate and private key that follow their files.
+type KeyPair struct {
+	r *reloadable[*tls.Certificate]
+}
+
+// LoadKeyPair reads a PEM certificate chain and key. Unlike a bare
+// tls.LoadX509KeyPair, the initial load must succeed.
+func LoadKeyPair(certFile, keyFile string) (*KeyPair, error) {
+	r, err := newReloadable(func() (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &cert, nil
+	}, certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("certs: loading key pair %v: %w", certFile, err)
+	}
+	return &KeyPair{r: r}, nil
+}
+
+func (k *KeyPair) Certificate() *tls.Certificate {
+	return k.r.get()
+}
+
+// Pool is a set of CA certificates that follows its file.
+type Pool struct {
+	r *reloadable[*x509.CertPool]
+}
+
+// LoadPool reads one or more PEM CA certificates from file.
+func LoadPool(file string) (*Pool, error) {
+	r, err := newReloadable(func() (*x509.CertPool, error) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no certificates found")
+		}
+		return pool, nil
+	}, file)
+	if err != nil {
+		return nil, fmt.Errorf("certs: loading CA pool %v: %w", file, err)
+	}
+	return &Pool{r: r}, nil
+}
+
+func (p *Pool) CertPool() *x509.CertPool {
+	return p.r.get()
+}
+
+// ServerConfig serves cert. With clientCAs it requires every client to
+// present a certificate signed by one of them, which is mutual TLS.
+// Each handshake sees the current certificate and CAs.
+func ServerConfig(cert *KeyPair, clientCAs *Pool) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert.Certificate()},
+				// The config returned here replaces the one gRPC set up,
+				// so it must offer HTTP/2 itself.
+				NextProtos: []string{"h2"},
+			}
+			if clientCAs != nil {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = clientCAs.CertPool()
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// ClientConfig verifies servers against rootCAs, or the system roots if it
+// is nil, and presents cert when the server asks for one. cert may be nil
+// for plain TLS.
+func ClientConfig(rootCAs *Pool, cert *KeyPair) *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cert != nil {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert.Certificate(), nil
+		}
+	}
+	if rootCAs != nil {
+		// tls.Config.RootCAs is fixed once set, so the standard verification
+		// is switched off and redone in VerifyConnection against the
+		// current pool. The server is still fully verified.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyServer(cs, rootCAs.CertPool())
+		}
+	}
+	return cfg
+}
+
+func verifyServer(cs tls.ConnectionState, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("certs: server presented no certificate")
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
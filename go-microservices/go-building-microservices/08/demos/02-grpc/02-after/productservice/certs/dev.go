@@ -0,0 +1,151 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DevFiles are the paths GenerateDev writes to.
+type DevFiles struct {
+	CA         string
+	ServerCert string
+	ServerKey  string
+	ClientCert string
+	ClientKey  string
+}
+
+func devFiles(dir string) DevFiles {
+	return DevFiles{
+		CA:         filepath.Join(dir, "ca.pem"),
+		ServerCert: filepath.Join(dir, "server.pem"),
+		ServerKey:  filepath.Join(dir, "server-key.pem"),
+		ClientCert: filepath.Join(dir, "client.pem"),
+		ClientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+}
+
+// devValidity is short on purpose: these certificates are for local
+// development only.
+const devValidity = 30 * 24 * time.Hour
+
+// GenerateDev creates a throwaway CA in dir, with a server certificate
+// for hosts and a client certificate for mutual TLS, all signed by it.
+// Files already in dir are reused, so restarts keep the same CA. The CA's
+// key is not written anywhere; to issue more certificates, delete dir.
+func GenerateDev(dir string, hosts ...string) (DevFiles, error) {
+	files := devFiles(dir)
+	if allExist(files.CA, files.ServerCert, files.ServerKey, files.ClientCert, files.ClientKey) {
+		return files, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return files, err
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return files, err
+	}
+	caTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "productservice dev CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caCert, caDER, err := sign(caTemplate, nil, &caKey.PublicKey, caKey)
+	if err != nil {
+		return files, err
+	}
+	if err := writePEM(files.CA, "CERTIFICATE", caDER, 0o644); err != nil {
+		return files, err
+	}
+
+	server := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0]},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			server.IPAddresses = append(server.IPAddresses, ip)
+		} else {
+			server.DNSNames = append(server.DNSNames, h)
+		}
+	}
+	if err := issue(server, caCert, caKey, files.ServerCert, files.ServerKey); err != nil {
+		return files, err
+	}
+
+	client := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "productservice dev client"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if err := issue(client, caCert, caKey, files.ClientCert, files.ClientKey); err != nil {
+		return files, err
+	}
+	return files, nil
+}
+
+// issue signs a new key for template with the CA and writes both out.
+func issue(template, ca *x509.Certificate, caKey *ecdsa.PrivateKey, certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	_, der, err := sign(template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := writePEM(keyFile, "PRIVATE KEY", keyDER, 0o600); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der, 0o644)
+}
+
+// sign fills in the serial number and validity of template and signs it
+// with parent, or self-signs it when parent is nil.
+func sign(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey) (*x509.Certificate, []byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(devValidity)
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("certs: signing %v: %w", template.Subject.CommonName, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, der, err
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm)
+}
+
+func allExist(paths ...string) bool {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			return false
+		}
+	}
+	return true
+}
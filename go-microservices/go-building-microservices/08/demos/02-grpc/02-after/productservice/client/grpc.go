@@ -0,0 +1,102 @@
+// Package client holds the interceptors gRPC clients of the product
+// service use: every call gets a deadline, and idempotent calls are
+// retried with jittered exponential backoff.
+package client
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Config struct {
+	// Timeout bounds a single attempt. A shorter deadline on the caller's
+	// context still wins.
+	Timeout time.Duration
+	// MaxRetries is how many times an idempotent call is repeated after
+	// the first attempt fails.
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Idempotent lists the full method names that are safe to repeat.
+	Idempotent map[string]bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:     2 * time.Second,
+		MaxRetries:  2,
+		BaseBackoff: 50 * time.Millisecond,
+		MaxBackoff:  1 * time.Second,
+	}
+}
+
+// UnaryClientInterceptor applies cfg to unary calls. Streams are left
+// alone, since a watch is meant to stay open for as long as the caller
+// wants it.
+func UnaryClientInterceptor(cfg Config) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attempts := 1
+		if cfg.Idempotent[method] {
+			attempts += cfg.MaxRetries
+		}
+
+		var err error
+		for attempt := 0; attempt < attempts; attempt++ {
+			if attempt > 0 {
+				log.Printf("Retrying %v: %v", method, err)
+				if err := sleep(ctx, cfg, attempt); err != nil {
+					return status.FromContextError(err).Err()
+				}
+			}
+
+			err = invokeWithTimeout(ctx, cfg.Timeout, method, req, reply, cc, invoker, opts...)
+			if err == nil || ctx.Err() != nil || !retryable(status.Code(err)) {
+				return err
+			}
+		}
+		return err
+	}
+}
+
+func invokeWithTimeout(ctx context.Context, timeout time.Duration, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// retryable reports codes that may succeed if the call is simply made
+// again. DEADLINE_EXCEEDED only counts when the attempt's own timeout ran
+// out; the caller's deadline is checked before retrying.
+func retryable(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// sleep waits for a random duration up to the exponential backoff for
+// this attempt ("full jitter"), or until ctx is done.
+func sleep(ctx context.Context, cfg Config, attempt int) error {
+	backoff := cfg.BaseBackoff << (attempt - 1)
+	if backoff > cfg.MaxBackoff || backoff <= 0 {
+		backoff = cfg.MaxBackoff
+	}
+	t := time.NewTimer(time.Duration(rand.Int63n(int64(backoff) + 1)))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -3,8 +3,10 @@ module demo
 go 1.21.0
 
 require (
+	github.com/golang-jwt/jwt/v5 v5.2.3
 	google.golang.org/grpc v1.59.0
 	google.golang.org/protobuf v1.31.0
+	shared v0.0.0
 )
 
 require (
@@ -14,3 +16,5 @@ require (
 	golang.org/x/text v0.12.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
 )
+
+replace shared => ../../../../../shared
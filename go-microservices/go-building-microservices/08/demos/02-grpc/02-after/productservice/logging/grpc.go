@@ -0,0 +1,82 @@
+// Package logging writes one structured log line for every RPC a server
+// handles.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type Config struct {
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+	// Attrs adds attributes taken from the call's context, such as a trace
+	// ID set by an interceptor further out.
+	Attrs func(ctx context.Context) []slog.Attr
+}
+
+// UnaryServerInterceptor logs each call once it has been handled, with
+// its status code and duration.
+func UnaryServerInterceptor(cfg Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		cfg.log(ctx, info.FullMethod, err, start)
+		return res, err
+	}
+}
+
+// StreamServerInterceptor logs each stream once it has ended.
+func StreamServerInterceptor(cfg Config) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		cfg.log(ss.Context(), info.FullMethod, err, start)
+		return err
+	}
+}
+
+func (cfg Config) log(ctx context.Context, method string, err error, start time.Time) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	code := status.Code(err)
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	if cfg.Attrs != nil {
+		attrs = append(attrs, cfg.Attrs(ctx)...)
+	}
+
+	level := slog.LevelInfo
+	if serverFault(code) {
+		level = slog.LevelError
+	}
+	logger.LogAttrs(ctx, level, "RPC handled", attrs...)
+}
+
+// serverFault reports codes that mean the server, not the caller, got
+// something wrong; the gRPC counterpart of a 5xx.
+func serverFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented, codes.Unavailable:
+		return true
+	}
+	return false
+}
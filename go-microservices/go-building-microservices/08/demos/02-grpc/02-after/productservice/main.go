@@ -2,76 +2,326 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"demo/auth"
+	"demo/client"
+	"demo/logging"
 	"demo/productpb"
+	"demo/recovery"
+	"demo/validate"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"net"
+	"net/http"
+	"os"
+	"shared/catalog"
+	"shared/lifecycle"
+	"shared/metrics"
+	"shared/ratelimit"
+	"shared/trace"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
-type Product struct {
-	ID         int
-	Name       string
-	USDPerUnit float64
-	Unit       string
-}
+// Product is the catalogue's product, so the seed data in products.go can
+// be loaded into the store.
+type Product = catalog.Product
 
 func main() {
 
-	go startGRPCServer()
+	// Spans go to TRACES_FILE as OTLP/JSON when it is set.
+	var exporter trace.Exporter
+	if path := os.Getenv("TRACES_FILE"); path != "" {
+		fe, err := trace.NewFileExporter(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fe.Close()
+		exporter = fe
+	}
+
+	transport, err := loadTransportConfig("localhost", "127.0.0.1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	serverCreds, err := transport.serverCredentials()
+	if err != nil {
+		log.Fatal(err)
+	}
+	clientCreds, err := transport.clientCredentials()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	time.Sleep(1 * time.Second)
+	tokens, verifier, err := loadAuth()
+	if err != nil {
+		log.Fatal(err)
+	}
+	token, err := tokens.Sign("demo-client", time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bearer := auth.BearerToken{Token: token, AllowInsecure: transport.mode == "off"}
 
-	callGRPCService()
+	reg := metrics.NewRegistry()
+	reg.Register(metrics.NewRuntimeCollector())
 
-}
+	store := catalog.NewStore(products)
+	grpcServer, healthServer := createGRPCServer(store, serverCreds, verifier, reg, trace.NewTracer("product", exporter))
 
-type ProductService struct {
-	productpb.UnimplementedProductServer
+	// The demo client runs once both servers are listening, and the
+	// process shuts down when it is done, or on SIGINT or SIGTERM.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sup := lifecycle.New()
+	sup.AddServer("product gRPC", "localhost:4001", lifecycle.GRPC(grpcServer))
+	sup.Add("metrics", createMetricsServer(reg))
+	sup.OnStarted(func() {
+		go func() {
+			defer cancel()
+			callGRPCService(clientCreds, bearer, trace.NewTracer("product-client", exporter))
+		}()
+	})
+	sup.OnShutdown(func() {
+		// Probes see NOT_SERVING while in-flight RPCs finish, rather than
+		// a server that vanishes.
+		healthServer.Shutdown()
+	})
+	if err := sup.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func (ps ProductService) GetProduct(ctx context.Context, req *productpb.GetProductRequest) (*productpb.GetProductReply, error) {
-	for _, p := range products {
-		if p.ID == int(req.ProductId) {
-			return &productpb.GetProductReply{
-					Product: &productpb.Product{
-						Id:         int32(p.ID),
-						Name:       p.Name,
-						UsdPerUnit: p.USDPerUnit,
-						Unit:       p.Unit,
-					},
-				},
-				nil
+// getProductPolicy matches the limit the HTTP product service applies to
+// product lookups and listings.
+var getProductPolicy = ratelimit.Policy{Name: "products", Limit: 600, Window: time.Minute}
+
+// loadAuth sets up the token checks. GRPC_JWT_KEY signs and verifies user
+// tokens; without it a random key is made for each run, which is enough
+// for the demo client below. GRPC_API_TOKENS adds opaque tokens for other
+// services, as comma-separated token=username pairs.
+func loadAuth() (auth.JWT, auth.Verifier, error) {
+	tokens := auth.JWT{Key: []byte(os.Getenv("GRPC_JWT_KEY")), Issuer: "productservice"}
+	if len(tokens.Key) == 0 {
+		tokens.Key = make([]byte, 32)
+		if _, err := rand.Read(tokens.Key); err != nil {
+			return auth.JWT{}, nil, fmt.Errorf("generating a JWT key: %w", err)
 		}
 	}
 
-	return nil, fmt.Errorf("product not found with ID: %v", req.ProductId)
+	apiTokens := auth.Tokens{}
+	for _, pair := range strings.Split(os.Getenv("GRPC_API_TOKENS"), ",") {
+		if token, user, ok := strings.Cut(strings.TrimSpace(pair), "="); ok && token != "" && user != "" {
+			apiTokens[token] = user
+		}
+	}
+	return tokens, auth.Any{tokens, apiTokens}, nil
 }
 
-func startGRPCServer() {
-	lis, err := net.Listen("tcp", "localhost:4001")
-	if err != nil {
-		log.Fatal(err)
+// publicMethods can be called without a token, so health probes do not
+// need credentials.
+var publicMethods = map[string]bool{
+	grpc_health_v1.Health_Check_FullMethodName: true,
+	grpc_health_v1.Health_Watch_FullMethodName: true,
+}
+
+// byPrincipal keys RPCs by the user the auth interceptor authenticated,
+// so the limit follows the caller rather than a header it chose. Calls
+// without one are keyed by address.
+func byPrincipal(ctx context.Context) string {
+	if claims, ok := auth.FromContext(ctx); ok {
+		return "user:" + claims.Username
+	}
+	return ratelimit.ByPeer(ctx)
+}
+
+// createGRPCServer serves store over gRPC. The health server is returned
+// so shutdown can report NOT_SERVING before the server drains.
+func createGRPCServer(store *catalog.Store, creds credentials.TransportCredentials, verifier auth.Verifier, reg *metrics.Registry, tracer *trace.Tracer) (*grpc.Server, *health.Server) {
+	limits := ratelimit.UnaryServerInterceptor(
+		ratelimit.NewMemoryStore(),
+		byPrincipal,
+		map[string]ratelimit.Policy{
+			productpb.Product_GetProduct_FullMethodName:   getProductPolicy,
+			productpb.Product_ListProducts_FullMethodName: getProductPolicy,
+		},
+	)
+
+	// Logging and metrics sit outside recovery so that they see a panic as
+	// the INTERNAL error the client gets. Callers are authenticated before
+	// they count against a rate limit or have their requests checked.
+	logs := logging.Config{Attrs: trace.LogAttrs}
+	serverMetrics := metrics.NewServerMetrics(reg)
+	opts := []grpc.ServerOption{
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(
+			trace.UnaryServerInterceptor(tracer),
+			logging.UnaryServerInterceptor(logs),
+			serverMetrics.UnaryServerInterceptor(),
+			recovery.UnaryServerInterceptor(nil),
+			auth.UnaryServerInterceptor(verifier, publicMethods),
+			limits,
+			validate.UnaryServerInterceptor(requestRules),
+		),
+		grpc.ChainStreamInterceptor(
+			trace.StreamServerInterceptor(tracer),
+			logging.StreamServerInterceptor(logs),
+			serverMetrics.StreamServerInterceptor(),
+			recovery.StreamServerInterceptor(nil),
+			auth.StreamServerInterceptor(verifier, publicMethods),
+			validate.StreamServerInterceptor(requestRules),
+		),
 	}
-	var opts []grpc.ServerOption
 	grpcServer := grpc.NewServer(opts...)
-	productpb.RegisterProductServer(grpcServer, &ProductService{})
-	log.Fatal(grpcServer.Serve(lis))
+	productpb.RegisterProductServer(grpcServer, NewProductService(store))
+
+	// The standard health service lets load balancers and grpc_health_probe
+	// check the server. "" is the status of the server as a whole.
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(productpb.Product_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+
+	return grpcServer, healthServer
+}
+
+// createMetricsServer exposes the server's metrics for Prometheus to
+// scrape.
+func createMetricsServer(reg *metrics.Registry) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg.Handler())
+	return &http.Server{Addr: "localhost:4002", Handler: mux}
 }
 
-func callGRPCService() {
-	opts := []grpc.DialOption{grpc.WithInsecure()}
+// clientConfig bounds every call and retries the ones that are safe to
+// repeat.
+func clientConfig() client.Config {
+	cfg := client.DefaultConfig()
+	cfg.Idempotent = map[string]bool{
+		productpb.Product_GetProduct_FullMethodName:    true,
+		productpb.Product_ListProducts_FullMethodName:  true,
+		productpb.Product_UpdateProduct_FullMethodName: true,
+	}
+	return cfg
+}
+
+func callGRPCService(creds credentials.TransportCredentials, bearer auth.BearerToken, tracer *trace.Tracer) {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(bearer),
+		grpc.WithChainUnaryInterceptor(
+			client.UnaryClientInterceptor(clientConfig()),
+			trace.UnaryClientInterceptor(tracer),
+		),
+	}
 	conn, err := grpc.Dial("localhost:4001", opts...)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer conn.Close()
+
+	hc, err := grpc_health_v1.NewHealthClient(conn).Check(context.TODO(), &grpc_health_v1.HealthCheckRequest{
+		Service: productpb.Product_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Health:", hc.Status)
+
 	client := productpb.NewProductClient(conn)
 	res, err := client.GetProduct(context.TODO(), &productpb.GetProductRequest{ProductId: 3})
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Println(res.Product)
+
+	page, err := client.ListProducts(context.TODO(), &productpb.ListProductsRequest{
+		PageSize: 2,
+		Filter:   &productpb.ProductFilter{Unit: "pound"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(page.TotalSize, "products sold by the pound, first page:", page.Products)
+
+	// FromStatus turns the service's status codes back into the catalogue's
+	// own errors.
+	_, err = client.GetProduct(context.TODO(), &productpb.GetProductRequest{ProductId: 99})
+	if err := catalog.FromStatus(err); errors.Is(err, catalog.ErrNotFound) {
+		fmt.Println("Not found:", err)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+
+	watchAndQuote(client)
+}
+
+// watchAndQuote holds a quote for a small cart open while a price in it
+// changes, and shows both the watch stream and the quote stream picking up
+// the change.
+func watchAndQuote(client productpb.ProductClient) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	watch, err := client.WatchProducts(ctx, &productpb.WatchProductsRequest{
+		Filter: &productpb.ProductFilter{Unit: "each"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	snapshot, err := watch.Recv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Watching:", snapshot.GetSnapshot().GetProducts())
+
+	quotes, err := client.PriceQuote(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cart := &productpb.PriceQuoteRequest{Items: []*productpb.QuoteItem{
+		{ProductId: 3, Quantity: 2},
+		{ProductId: 5, Quantity: 1},
+	}}
+	if err := quotes.Send(cart); err != nil {
+		log.Fatal(err)
+	}
+	quote, err := quotes.Recv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Quote:", quote.TotalCents)
+
+	_, err = client.UpdateProduct(ctx, &productpb.UpdateProductRequest{
+		Product:    &productpb.Product{Id: 3, UsdPerUnit: 3.99},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"usdPerUnit"}},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	change, err := watch.Recv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Change:", change.GetChange())
+	quote, err = quotes.Recv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("New quote:", quote.TotalCents)
+
+	if err := quotes.CloseSend(); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := quotes.Recv(); err != io.EOF {
+		log.Fatal(err)
+	}
 }
@@ -19,7 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Product_GetProduct_FullMethodName = "/productService.Product/GetProduct"
+	Product_GetProduct_FullMethodName    = "/productService.Product/GetProduct"
+	Product_ListProducts_FullMethodName  = "/productService.Product/ListProducts"
+	Product_CreateProduct_FullMethodName = "/productService.Product/CreateProduct"
+	Product_UpdateProduct_FullMethodName = "/productService.Product/UpdateProduct"
+	Product_DeleteProduct_FullMethodName = "/productService.Product/DeleteProduct"
+	Product_WatchProducts_FullMethodName = "/productService.Product/WatchProducts"
+	Product_PriceQuote_FullMethodName    = "/productService.Product/PriceQuote"
 )
 
 // ProductClient is the client API for Product service.
@@ -27,6 +33,18 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ProductClient interface {
 	GetProduct(ctx context.Context, in *GetProductRequest, opts ...grpc.CallOption) (*GetProductReply, error)
+	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsReply, error)
+	CreateProduct(ctx context.Context, in *CreateProductRequest, opts ...grpc.CallOption) (*CreateProductReply, error)
+	UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*UpdateProductReply, error)
+	DeleteProduct(ctx context.Context, in *DeleteProductRequest, opts ...grpc.CallOption) (*DeleteProductReply, error)
+	// WatchProducts streams the matching products and then every change to
+	// them until the client cancels. A client that falls too far behind is
+	// ended with ABORTED and should watch again.
+	WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (Product_WatchProductsClient, error)
+	// PriceQuote answers each cart the client sends with a quote, and sends
+	// a new quote whenever a product in the current cart changes. It ends
+	// when the client closes its side of the stream.
+	PriceQuote(ctx context.Context, opts ...grpc.CallOption) (Product_PriceQuoteClient, error)
 }
 
 type productClient struct {
@@ -46,11 +64,122 @@ func (c *productClient) GetProduct(ctx context.Context, in *GetProductRequest, o
 	return out, nil
 }
 
+func (c *productClient) ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsReply, error) {
+	out := new(ListProductsReply)
+	err := c.cc.Invoke(ctx, Product_ListProducts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productClient) CreateProduct(ctx context.Context, in *CreateProductRequest, opts ...grpc.CallOption) (*CreateProductReply, error) {
+	out := new(CreateProductReply)
+	err := c.cc.Invoke(ctx, Product_CreateProduct_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productClient) UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*UpdateProductReply, error) {
+	out := new(UpdateProductReply)
+	err := c.cc.Invoke(ctx, Product_UpdateProduct_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productClient) DeleteProduct(ctx context.Context, in *DeleteProductRequest, opts ...grpc.CallOption) (*DeleteProductReply, error) {
+	out := new(DeleteProductReply)
+	err := c.cc.Invoke(ctx, Product_DeleteProduct_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productClient) WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (Product_WatchProductsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Product_ServiceDesc.Streams[0], Product_WatchProducts_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &productWatchProductsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Product_WatchProductsClient interface {
+	Recv() (*WatchProductsReply, error)
+	grpc.ClientStream
+}
+
+type productWatchProductsClient struct {
+	grpc.ClientStream
+}
+
+func (x *productWatchProductsClient) Recv() (*WatchProductsReply, error) {
+	m := new(WatchProductsReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *productClient) PriceQuote(ctx context.Context, opts ...grpc.CallOption) (Product_PriceQuoteClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Product_ServiceDesc.Streams[1], Product_PriceQuote_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &productPriceQuoteClient{stream}
+	return x, nil
+}
+
+type Product_PriceQuoteClient interface {
+	Send(*PriceQuoteRequest) error
+	Recv() (*PriceQuoteReply, error)
+	grpc.ClientStream
+}
+
+type productPriceQuoteClient struct {
+	grpc.ClientStream
+}
+
+func (x *productPriceQuoteClient) Send(m *PriceQuoteRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *productPriceQuoteClient) Recv() (*PriceQuoteReply, error) {
+	m := new(PriceQuoteReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ProductServer is the server API for Product service.
 // All implementations must embed UnimplementedProductServer
 // for forward compatibility
 type ProductServer interface {
 	GetProduct(context.Context, *GetProductRequest) (*GetProductReply, error)
+	ListProducts(context.Context, *ListProductsRequest) (*ListProductsReply, error)
+	CreateProduct(context.Context, *CreateProductRequest) (*CreateProductReply, error)
+	UpdateProduct(context.Context, *UpdateProductRequest) (*UpdateProductReply, error)
+	DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductReply, error)
+	// WatchProducts streams the matching products and then every change to
+	// them until the client cancels. A client that falls too far behind is
+	// ended with ABORTED and should watch again.
+	WatchProducts(*WatchProductsRequest, Product_WatchProductsServer) error
+	// PriceQuote answers each cart the client sends with a quote, and sends
+	// a new quote whenever a product in the current cart changes. It ends
+	// when the client closes its side of the stream.
+	PriceQuote(Product_PriceQuoteServer) error
 	mustEmbedUnimplementedProductServer()
 }
 
@@ -61,6 +190,24 @@ type UnimplementedProductServer struct {
 func (UnimplementedProductServer) GetProduct(context.Context, *GetProductRequest) (*GetProductReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProduct not implemented")
 }
+func (UnimplementedProductServer) ListProducts(context.Context, *ListProductsRequest) (*ListProductsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
+}
+func (UnimplementedProductServer) CreateProduct(context.Context, *CreateProductRequest) (*CreateProductReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateProduct not implemented")
+}
+func (UnimplementedProductServer) UpdateProduct(context.Context, *UpdateProductRequest) (*UpdateProductReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProduct not implemented")
+}
+func (UnimplementedProductServer) DeleteProduct(context.Context, *DeleteProductRequest) (*DeleteProductReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProduct not implemented")
+}
+func (UnimplementedProductServer) WatchProducts(*WatchProductsRequest, Product_WatchProductsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchProducts not implemented")
+}
+func (UnimplementedProductServer) PriceQuote(Product_PriceQuoteServer) error {
+	return status.Errorf(codes.Unimplemented, "method PriceQuote not implemented")
+}
 func (UnimplementedProductServer) mustEmbedUnimplementedProductServer() {}
 
 // UnsafeProductServer may be embedded to opt out of forward compatibility for this service.
@@ -92,6 +239,125 @@ func _Product_GetProduct_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Product_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServer).ListProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Product_ListProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServer).ListProducts(ctx, req.(*ListProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Product_CreateProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServer).CreateProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Product_CreateProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServer).CreateProduct(ctx, req.(*CreateProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Product_UpdateProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServer).UpdateProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Product_UpdateProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServer).UpdateProduct(ctx, req.(*UpdateProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Product_DeleteProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServer).DeleteProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Product_DeleteProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServer).DeleteProduct(ctx, req.(*DeleteProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Product_WatchProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductServer).WatchProducts(m, &productWatchProductsServer{stream})
+}
+
+type Product_WatchProductsServer interface {
+	Send(*WatchProductsReply) error
+	grpc.ServerStream
+}
+
+type productWatchProductsServer struct {
+	grpc.ServerStream
+}
+
+func (x *productWatchProductsServer) Send(m *WatchProductsReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Product_PriceQuote_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProductServer).PriceQuote(&productPriceQuoteServer{stream})
+}
+
+type Product_PriceQuoteServer interface {
+	Send(*PriceQuoteReply) error
+	Recv() (*PriceQuoteRequest, error)
+	grpc.ServerStream
+}
+
+type productPriceQuoteServer struct {
+	grpc.ServerStream
+}
+
+func (x *productPriceQuoteServer) Send(m *PriceQuoteReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *productPriceQuoteServer) Recv() (*PriceQuoteRequest, error) {
+	m := new(PriceQuoteRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Product_ServiceDesc is the grpc.ServiceDesc for Product service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -103,7 +369,35 @@ var Product_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetProduct",
 			Handler:    _Product_GetProduct_Handler,
 		},
+		{
+			MethodName: "ListProducts",
+			Handler:    _Product_ListProducts_Handler,
+		},
+		{
+			MethodName: "CreateProduct",
+			Handler:    _Product_CreateProduct_Handler,
+		},
+		{
+			MethodName: "UpdateProduct",
+			Handler:    _Product_UpdateProduct_Handler,
+		},
+		{
+			MethodName: "DeleteProduct",
+			Handler:    _Product_DeleteProduct_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchProducts",
+			Handler:       _Product_WatchProducts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PriceQuote",
+			Handler:       _Product_PriceQuote_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "productservice.proto",
 }
@@ -0,0 +1,46 @@
+// Package recovery stops a panicking RPC handler from taking the server
+// down with it.
+package recovery
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor turns a panic in the handler into an INTERNAL
+// error for the caller and logs it with the stack. The panic value is not
+// sent to the client.
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+		defer recoverInto(ctx, logger, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverInto(ss.Context(), logger, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+func recoverInto(ctx context.Context, logger *slog.Logger, method string, err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.ErrorContext(ctx, "RPC handler panicked",
+		slog.String("method", method),
+		slog.Any("panic", p),
+		slog.String("stack", string(debug.Stack())),
+	)
+	*err = status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"demo/productpb"
+	"demo/validate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"shared/catalog"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type ProductService struct {
+	productpb.UnimplementedProductServer
+	store *catalog.Store
+}
+
+func NewProductService(store *catalog.Store) *ProductService {
+	return &ProductService{store: store}
+}
+
+func toProto(p catalog.Product) *productpb.Product {
+	return &productpb.Product{Id: int32(p.ID), Name: p.Name, UsdPerUnit: p.USDPerUnit, Unit: p.Unit}
+}
+
+func fromProto(m *productpb.Product) catalog.Product {
+	return catalog.Product{ID: int(m.GetId()), Name: m.GetName(), USDPerUnit: m.GetUsdPerUnit(), Unit: m.GetUnit()}
+}
+
+func (ps *ProductService) GetProduct(ctx context.Context, req *productpb.GetProductRequest) (*productpb.GetProductReply, error) {
+	if err := positiveID("productId", req.ProductId); err != nil {
+		return nil, err
+	}
+	id := int(req.ProductId)
+	if err := ctx.Err(); err != nil {
+		return nil, catalog.Status(err, id)
+	}
+	p, err := ps.store.Get(id)
+	if err != nil {
+		return nil, catalog.Status(err, id)
+	}
+	return &productpb.GetProductReply{Product: toProto(p)}, nil
+}
+
+// ListProducts returns the products matching the request's filter in
+// order of ID. Pages continue after the last ID of the previous page, so
+// products created or deleted between calls do not shift the pages.
+func (ps *ProductService) ListProducts(ctx context.Context, req *productpb.ListProductsRequest) (*productpb.ListProductsReply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, catalog.Status(err, 0)
+	}
+	size := int(req.PageSize)
+	switch {
+	case size < 0:
+		return nil, invalidArgument("pageSize", "must not be negative")
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+
+	filter := req.GetFilter()
+	after := 0
+	if req.PageToken != "" {
+		tok, err := decodePageToken(req.PageToken)
+		if err != nil || tok.Filter != filterKey(filter) {
+			return nil, invalidArgument("pageToken", "is not a token issued for this filter")
+		}
+		after = tok.After
+	}
+
+	var matched []catalog.Product
+	for _, p := range ps.store.List() {
+		if matchesFilter(p, filter) {
+			matched = append(matched, p)
+		}
+	}
+
+	reply := &productpb.ListProductsReply{TotalSize: int32(len(matched))}
+	for _, p := range matched {
+		if p.ID <= after {
+			continue
+		}
+		if len(reply.Products) == size {
+			last := reply.Products[len(reply.Products)-1]
+			reply.NextPageToken = pageToken{After: int(last.Id), Filter: filterKey(filter)}.encode()
+			break
+		}
+		reply.Products = append(reply.Products, toProto(p))
+	}
+	return reply, nil
+}
+
+func (ps *ProductService) CreateProduct(ctx context.Context, req *productpb.CreateProductRequest) (*productpb.CreateProductReply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, catalog.Status(err, 0)
+	}
+	p, err := ps.store.Create(fromProto(req.Product))
+	if err != nil {
+		return nil, catalog.Status(err, 0)
+	}
+	return &productpb.CreateProductReply{Product: toProto(p)}, nil
+}
+
+// UpdateProduct copies the fields named in the update mask onto the
+// stored product. Without a mask every field is replaced.
+func (ps *ProductService) UpdateProduct(ctx context.Context, req *productpb.UpdateProductRequest) (*productpb.UpdateProductReply, error) {
+	// requestRules refuse a missing product or ID, but the handler must
+	// not depend on the interceptor being installed.
+	if req.GetProduct() == nil {
+		return nil, invalidArgument("product", "is required")
+	}
+	if err := positiveID("product.id", req.Product.Id); err != nil {
+		return nil, err
+	}
+	id := int(req.Product.Id)
+	if err := ctx.Err(); err != nil {
+		return nil, catalog.Status(err, id)
+	}
+	paths, err := updatePaths(req.UpdateMask)
+	if err != nil {
+		return nil, catalog.Status(err, id)
+	}
+
+	src := fromProto(req.Product)
+	p, err := ps.store.Update(id, func(p *catalog.Product) error {
+		for _, path := range paths {
+			switch path {
+			case "name":
+				p.Name = src.Name
+			case "usdPerUnit":
+				p.USDPerUnit = src.USDPerUnit
+			case "unit":
+				p.Unit = src.Unit
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, catalog.Status(err, id)
+	}
+	return &productpb.UpdateProductReply{Product: toProto(p)}, nil
+}
+
+func (ps *ProductService) DeleteProduct(ctx context.Context, req *productpb.DeleteProductRequest) (*productpb.DeleteProductReply, error) {
+	if err := positiveID("productId", req.ProductId); err != nil {
+		return nil, err
+	}
+	id := int(req.ProductId)
+	if err := ctx.Err(); err != nil {
+		return nil, catalog.Status(err, id)
+	}
+	if err := ps.store.Delete(id); err != nil {
+		return nil, catalog.Status(err, id)
+	}
+	return &productpb.DeleteProductReply{}, nil
+}
+
+// updatableFields are the paths an update mask may name.
+var updatableFields = []string{"name", "usdPerUnit", "unit"}
+
+// updatePaths validates mask and expands an empty mask or "*" to every
+// updatable field.
+func updatePaths(mask *fieldmaskpb.FieldMask) ([]string, error) {
+	if len(mask.GetPaths()) == 0 || (len(mask.Paths) == 1 && mask.Paths[0] == "*") {
+		return updatableFields, nil
+	}
+	if !mask.IsValid(&productpb.Product{}) {
+		return nil, &catalog.FieldError{Field: "updateMask", Reason: fmt.Sprintf("unknown field in %v", strings.Join(mask.Paths, ", "))}
+	}
+	mask.Normalize()
+	for _, path := range mask.Paths {
+		if path == "id" {
+			return nil, &catalog.FieldError{Field: "updateMask", Reason: "id cannot be changed"}
+		}
+	}
+	return mask.Paths, nil
+}
+
+func matchesFilter(p catalog.Product, f *productpb.ProductFilter) bool {
+	if f == nil {
+		return true
+	}
+	if f.Name != "" && !strings.EqualFold(p.Name, f.Name) {
+		return false
+	}
+	if f.Unit != "" && !strings.EqualFold(p.Unit, f.Unit) {
+		return false
+	}
+	// Compare whole cents, as the HTTP service does, so 1.99 always
+	// matches a bound of 1.99.
+	price := math.Round(p.USDPerUnit * 100)
+	if f.MinUsdPerUnit != nil && price < math.Round(*f.MinUsdPerUnit*100) {
+		return false
+	}
+	if f.MaxUsdPerUnit != nil && price > math.Round(*f.MaxUsdPerUnit*100) {
+		return false
+	}
+	return true
+}
+
+// pageToken marks the last product of a page. Filter fingerprints the
+// filter the token was issued for, so it cannot be replayed with another.
+type pageToken struct {
+	After  int    `json:"after"`
+	Filter string `json:"filter"`
+}
+
+func (t pageToken) encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(s string) (pageToken, error) {
+	var t pageToken
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+func filterKey(f *productpb.ProductFilter) string {
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(f)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+// requestRules check the shape of each request before it reaches the
+// handlers above, which can then rely on it.
+var requestRules = validate.Rules{
+	productpb.Product_GetProduct_FullMethodName: validate.For(func(req *productpb.GetProductRequest) error {
+		return positiveID("productId", req.ProductId)
+	}),
+	productpb.Product_ListProducts_FullMethodName: validate.For(func(req *productpb.ListProductsRequest) error {
+		if req.PageSize < 0 {
+			return invalidArgument("pageSize", "must not be negative")
+		}
+		return nil
+	}),
+	productpb.Product_CreateProduct_FullMethodName: validate.For(func(req *productpb.CreateProductRequest) error {
+		switch {
+		case req.Product == nil:
+			return invalidArgument("product", "is required")
+		case req.Product.Id != 0:
+			return invalidArgument("product.id", "is assigned by the server")
+		}
+		return nil
+	}),
+	productpb.Product_UpdateProduct_FullMethodName: validate.For(func(req *productpb.UpdateProductRequest) error {
+		if req.Product == nil {
+			return invalidArgument("product", "is required")
+		}
+		if _, err := updatePaths(req.UpdateMask); err != nil {
+			return catalog.Status(err, 0)
+		}
+		return positiveID("product.id", req.Product.Id)
+	}),
+	productpb.Product_DeleteProduct_FullMethodName: validate.For(func(req *productpb.DeleteProductRequest) error {
+		return positiveID("productId", req.ProductId)
+	}),
+	productpb.Product_PriceQuote_FullMethodName: validate.For(func(req *productpb.PriceQuoteRequest) error {
+		return catalog.Status(validateQuote(req.Items), 0)
+	}),
+}
+
+func positiveID(field string, id int32) error {
+	if id <= 0 {
+		return invalidArgument(field, "must be positive")
+	}
+	return nil
+}
+
+func invalidArgument(field, reason string) error {
+	return catalog.Status(&catalog.FieldError{Field: field, Reason: reason}, 0)
+}
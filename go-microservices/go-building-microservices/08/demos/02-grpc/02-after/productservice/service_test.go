@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"demo/productpb"
+	"errors"
+	"net"
+	"shared/catalog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var seed = []catalog.Product{
+	{ID: 1, Name: "Apples", USDPerUnit: 1.99, Unit: "Pound"},
+	{ID: 2, Name: "Oranges", USDPerUnit: 2.99, Unit: "Pound"},
+	{ID: 3, Name: "Bread", USDPerUnit: 3.49, Unit: "Each"},
+}
+
+// testServer serves a ProductService over an in-memory connection. Every stream
+// handler's result is sent on streamDone once it returns.
+type testServer struct {
+	store      *catalog.Store
+	client     productpb.ProductClient
+	streamDone chan error
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	ts := &testServer{store: catalog.NewStore(seed), streamDone: make(chan error, 10)}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		ts.streamDone <- err
+		return err
+	}))
+	productpb.RegisterProductServer(srv, NewProductService(ts.store))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ts.client = productpb.NewProductClient(conn)
+	return ts
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestGetProductNotFound(t *testing.T) {
+	ts := newTestServer(t)
+	_, err := ts.client.GetProduct(testContext(t), &productpb.GetProductRequest{ProductId: 99})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("error = %v, want NotFound", err)
+	}
+	if err := catalog.FromStatus(err); !errors.Is(err, catalog.ErrNotFound) {
+		t.Errorf("FromStatus = %v, want catalog.ErrNotFound", err)
+	}
+}
+
+func TestWatchProductsSnapshotThenChanges(t *testing.T) {
+	ts := newTestServer(t)
+	stream, err := ts.client.WatchProducts(testContext(t), &productpb.WatchProductsRequest{
+		Filter: &productpb.ProductFilter{Unit: "pound"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := first.GetSnapshot()
+	if snapshot == nil || len(snapshot.Products) != 2 {
+		t.Fatalf("first message = %v, want a snapshot of the 2 products sold by the pound", first)
+	}
+
+	// A change outside the filter is not sent; moving a product into and
+	// out of it is an add and a removal.
+	ts.store.Update(3, func(p *catalog.Product) error { p.USDPerUnit = 3.99; return nil })
+	ts.store.Update(1, func(p *catalog.Product) error { p.USDPerUnit = 2.49; return nil })
+	ts.store.Update(3, func(p *catalog.Product) error { p.Unit = "Pound"; return nil })
+	ts.store.Update(2, func(p *catalog.Product) error { p.Unit = "Each"; return nil })
+
+	want := []struct {
+		kind productpb.ProductChange_Kind
+		id   int32
+	}{
+		{productpb.ProductChange_KIND_MODIFIED, 1},
+		{productpb.ProductChange_KIND_ADDED, 3},
+		{productpb.ProductChange_KIND_REMOVED, 2},
+	}
+	for _, w := range want {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := msg.GetChange()
+		if c == nil || c.Kind != w.kind || c.Product.Id != w.id {
+			t.Fatalf("got %v, want %v of product %v", msg, w.kind, w.id)
+		}
+	}
+}
+
+func TestWatchProductsClientCancel(t *testing.T) {
+	ts := newTestServer(t)
+	ctx, cancel := context.WithCancel(testContext(t))
+	stream, err := ts.client.WatchProducts(ctx, &productpb.WatchProductsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case err := <-ts.streamDone:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("handler returned %v, want Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still running after the client cancelled")
+	}
+}
+
+func TestPriceQuote(t *testing.T) {
+	ts := newTestServer(t)
+	stream, err := ts.client.PriceQuote(testContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stream.Send(&productpb.PriceQuoteRequest{Items: []*productpb.QuoteItem{
+		{ProductId: 1, Quantity: 3},
+		{ProductId: 3, Quantity: 2},
+		{ProductId: 99, Quantity: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	quote, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 x 199 + 2 x 349 cents.
+	if quote.TotalCents != 1295 || len(quote.Lines) != 2 || quote.Lines[1].LineTotalCents != 698 {
+		t.Errorf("quote = %v, want a total of 1295 cents over 2 lines", quote)
+	}
+	if len(quote.UnknownProductIds) != 1 || quote.UnknownProductIds[0] != 99 {
+		t.Errorf("unknown products = %v, want [99]", quote.UnknownProductIds)
+	}
+
+	// A price change in the cart re-prices it unasked.
+	ts.store.Update(3, func(p *catalog.Product) error { p.USDPerUnit = 3.99; return nil })
+	quote, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.TotalCents != 1395 {
+		t.Errorf("re-priced total = %v cents, want 1395", quote.TotalCents)
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-ts.streamDone:
+		if err != nil {
+			t.Errorf("handler returned %v after the client closed, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still running after the client closed its side")
+	}
+}
+
+func TestUpdateProductWithoutProduct(t *testing.T) {
+	// The test server has no validation interceptor, so this reaches the
+	// handler.
+	ts := newTestServer(t)
+	_, err := ts.client.UpdateProduct(testContext(t), &productpb.UpdateProductRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestListProductsNegativePageSize(t *testing.T) {
+	ts := newTestServer(t)
+	_, err := ts.client.ListProducts(testContext(t), &productpb.ListProductsRequest{PageSize: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestHandlersRejectNonPositiveIDs(t *testing.T) {
+	// Called directly, without the validation interceptor in front.
+	ps := NewProductService(catalog.NewStore(seed))
+	ctx := testContext(t)
+	calls := map[string]func(id int32) error{
+		"GetProduct": func(id int32) error {
+			_, err := ps.GetProduct(ctx, &productpb.GetProductRequest{ProductId: id})
+			return err
+		},
+		"UpdateProduct": func(id int32) error {
+			_, err := ps.UpdateProduct(ctx, &productpb.UpdateProductRequest{Product: &productpb.Product{Id: id}})
+			return err
+		},
+		"DeleteProduct": func(id int32) error {
+			_, err := ps.DeleteProduct(ctx, &productpb.DeleteProductRequest{ProductId: id})
+			return err
+		},
+	}
+	for name, call := range calls {
+		for _, id := range []int32{0, -1} {
+			err := call(id)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("%v(%v): error = %v, want InvalidArgument", name, id, err)
+			}
+			var fe *catalog.FieldError
+			if !errors.As(catalog.FromStatus(err), &fe) {
+				t.Errorf("%v(%v): error %v has no field violation", name, id, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"demo/productpb"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"shared/catalog"
+)
+
+// WatchProducts sends a snapshot of the products matching the filter and
+// then follows the store's changes. Send blocks while the client's flow
+// control window is full; if that lasts long enough for the store's
+// buffer to overflow, the watch ends with ABORTED instead of holding up
+// writers.
+func (ps *ProductService) WatchProducts(req *productpb.WatchProductsRequest, stream productpb.Product_WatchProductsServer) error {
+	ctx := stream.Context()
+	filter := req.GetFilter()
+
+	snapshot, w := ps.store.Watch()
+	defer w.Stop()
+
+	// visible holds the IDs the client currently sees, so updates that move
+	// a product into or out of the filter are reported as adds and
+	// removals.
+	visible := make(map[int]bool)
+	reply := &productpb.ProductSnapshot{}
+	for _, p := range snapshot {
+		if matchesFilter(p, filter) {
+			visible[p.ID] = true
+			reply.Products = append(reply.Products, toProto(p))
+		}
+	}
+	if err := stream.Send(&productpb.WatchProductsReply{Event: &productpb.WatchProductsReply_Snapshot{Snapshot: reply}}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return catalog.Status(ctx.Err(), 0)
+		case c, ok := <-w.Changes():
+			if !ok {
+				return catalog.Status(w.Err(), 0)
+			}
+			kind := changeKind(c, visible[c.Product.ID], matchesFilter(c.Product, filter))
+			if kind == productpb.ProductChange_KIND_UNSPECIFIED {
+				continue
+			}
+			visible[c.Product.ID] = kind != productpb.ProductChange_KIND_REMOVED
+			change := &productpb.ProductChange{Kind: kind, Product: toProto(c.Product)}
+			if err := stream.Send(&productpb.WatchProductsReply{Event: &productpb.WatchProductsReply_Change{Change: change}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// changeKind decides how a change looks to a client that was or was not
+// seeing the product, given whether its new state matches the filter.
+// KIND_UNSPECIFIED means the client need not hear about it.
+func changeKind(c catalog.Change, wasVisible, matches bool) productpb.ProductChange_Kind {
+	switch {
+	case c.Kind == catalog.Deleted || !matches:
+		if wasVisible {
+			return productpb.ProductChange_KIND_REMOVED
+		}
+	case wasVisible:
+		return productpb.ProductChange_KIND_MODIFIED
+	default:
+		return productpb.ProductChange_KIND_ADDED
+	}
+	return productpb.ProductChange_KIND_UNSPECIFIED
+}
+
+// PriceQuote prices each cart the client sends and re-prices the current
+// one whenever a product in it changes. Receiving runs in its own
+// goroutine so that price changes are sent while the client is idle; all
+// sends happen here, since a stream must not be sent on concurrently.
+func (ps *ProductService) PriceQuote(stream productpb.Product_PriceQuoteServer) error {
+	ctx := stream.Context()
+
+	_, w := ps.store.Watch()
+	defer func() { w.Stop() }()
+
+	type received struct {
+		req *productpb.PriceQuoteRequest
+		err error
+	}
+	// The channel is unbuffered, so the client cannot get further ahead
+	// than one cart: gRPC's own flow control holds the rest.
+	carts := make(chan received)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			select {
+			case carts <- received{req, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var items []*productpb.QuoteItem
+	haveCart := false
+	for {
+		select {
+		case <-ctx.Done():
+			return catalog.Status(ctx.Err(), 0)
+
+		case r := <-carts:
+			if errors.Is(r.err, io.EOF) {
+				return nil
+			}
+			if r.err != nil {
+				return r.err
+			}
+			items, haveCart = r.req.Items, true
+
+		case c, ok := <-w.Changes():
+			if !ok {
+				// Dropped for falling behind. Nothing is lost by watching
+				// again, since every quote reads the store afresh.
+				_, w = ps.store.Watch()
+			} else if !quotes(items, c.Product.ID) {
+				continue
+			}
+		}
+		if !haveCart {
+			continue
+		}
+
+		if err := stream.Send(ps.quote(items)); err != nil {
+			return err
+		}
+	}
+}
+
+func validateQuote(items []*productpb.QuoteItem) error {
+	for i, item := range items {
+		if item.ProductId <= 0 {
+			return &catalog.FieldError{Field: fmt.Sprintf("items[%v].productId", i), Reason: "must be positive"}
+		}
+		if item.Quantity <= 0 {
+			return &catalog.FieldError{Field: fmt.Sprintf("items[%v].quantity", i), Reason: "must be positive"}
+		}
+	}
+	return nil
+}
+
+func quotes(items []*productpb.QuoteItem, id int) bool {
+	for _, item := range items {
+		if int(item.ProductId) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// quote prices items in whole cents, rounding each unit price once.
+func (ps *ProductService) quote(items []*productpb.QuoteItem) *productpb.PriceQuoteReply {
+	reply := &productpb.PriceQuoteReply{}
+	for _, item := range items {
+		p, err := ps.store.Get(int(item.ProductId))
+		if err != nil {
+			reply.UnknownProductIds = append(reply.UnknownProductIds, item.ProductId)
+			continue
+		}
+		unit := int64(math.Round(p.USDPerUnit * 100))
+		line := &productpb.QuoteLine{
+			ProductId:      item.ProductId,
+			Name:           p.Name,
+			Quantity:       item.Quantity,
+			UnitPriceCents: unit,
+			LineTotalCents: unit * int64(item.Quantity),
+		}
+		reply.Lines = append(reply.Lines, line)
+		reply.TotalCents += line.LineTotalCents
+	}
+	return reply
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"demo/certs"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// transportConfig selects how the gRPC server and client secure their
+// connection, from the environment:
+//
+//	GRPC_TLS             off (the default), tls or mtls
+//	GRPC_TLS_CERT/_KEY   the server's certificate and key
+//	GRPC_TLS_CA          CA that signs the server's certificate and, for
+//	                     mtls, the client's
+//	GRPC_TLS_CLIENT_CERT/_KEY
+//	                     the client's certificate and key for mtls
+//	GRPC_TLS_DEV_DIR     where a dev CA is generated when GRPC_TLS is on
+//	                     but no files are named; defaults to dev-certs
+//
+// The files are watched, so rotated certificates take effect without a
+// restart.
+type transportConfig struct {
+	mode           string
+	caFile         string
+	certFile       string
+	keyFile        string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+func loadTransportConfig(hosts ...string) (transportConfig, error) {
+	cfg := transportConfig{
+		mode:           os.Getenv("GRPC_TLS"),
+		caFile:         os.Getenv("GRPC_TLS_CA"),
+		certFile:       os.Getenv("GRPC_TLS_CERT"),
+		keyFile:        os.Getenv("GRPC_TLS_KEY"),
+		clientCertFile: os.Getenv("GRPC_TLS_CLIENT_CERT"),
+		clientKeyFile:  os.Getenv("GRPC_TLS_CLIENT_KEY"),
+	}
+	switch cfg.mode {
+	case "", "off":
+		cfg.mode = "off"
+		return cfg, nil
+	case "tls", "mtls":
+	default:
+		return cfg, fmt.Errorf("GRPC_TLS: unknown mode %q, want off, tls or mtls", cfg.mode)
+	}
+
+	if cfg.certFile == "" {
+		dir := os.Getenv("GRPC_TLS_DEV_DIR")
+		if dir == "" {
+			dir = "dev-certs"
+		}
+		dev, err := certs.GenerateDev(dir, hosts...)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.caFile, cfg.certFile, cfg.keyFile = dev.CA, dev.ServerCert, dev.ServerKey
+		cfg.clientCertFile, cfg.clientKeyFile = dev.ClientCert, dev.ClientKey
+	}
+	return cfg, nil
+}
+
+func (cfg transportConfig) serverCredentials() (credentials.TransportCredentials, error) {
+	if cfg.mode == "off" {
+		return insecure.NewCredentials(), nil
+	}
+	cert, err := certs.LoadKeyPair(cfg.certFile, cfg.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	var clientCAs *certs.Pool
+	if cfg.mode == "mtls" {
+		if cfg.caFile == "" {
+			return nil, fmt.Errorf("GRPC_TLS=mtls needs GRPC_TLS_CA to verify clients")
+		}
+		if clientCAs, err = certs.LoadPool(cfg.caFile); err != nil {
+			return nil, err
+		}
+	}
+	return credentials.NewTLS(certs.ServerConfig(cert, clientCAs)), nil
+}
+
+func (cfg transportConfig) clientCredentials() (credentials.TransportCredentials, error) {
+	if cfg.mode == "off" {
+		return insecure.NewCredentials(), nil
+	}
+	var roots *certs.Pool
+	if cfg.caFile != "" {
+		var err error
+		if roots, err = certs.LoadPool(cfg.caFile); err != nil {
+			return nil, err
+		}
+	}
+	var cert *certs.KeyPair
+	if cfg.mode == "mtls" {
+		if cfg.clientCertFile == "" {
+			return nil, fmt.Errorf("GRPC_TLS=mtls needs GRPC_TLS_CLIENT_CERT")
+		}
+		var err error
+		if cert, err = certs.LoadKeyPair(cfg.clientCertFile, cfg.clientKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	return credentials.NewTLS(certs.ClientConfig(roots, cert)), nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"demo/certs"
+	"testing"
+)
+
+func TestMutualTLSChecksEachSideSeparately(t *testing.T) {
+	dev, err := certs.GenerateDev(t.TempDir(), "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GRPC_TLS", "mtls")
+	t.Setenv("GRPC_TLS_CERT", dev.ServerCert)
+	t.Setenv("GRPC_TLS_KEY", dev.ServerKey)
+	t.Setenv("GRPC_TLS_CA", dev.CA)
+
+	// A server needs the CA to verify clients, but no client certificate.
+	cfg, err := loadTransportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.serverCredentials(); err != nil {
+		t.Errorf("server without a client certificate: %v", err)
+	}
+	if _, err := cfg.clientCredentials(); err == nil {
+		t.Error("client without a certificate was configured for mtls")
+	}
+
+	cfg.caFile = ""
+	if _, err := cfg.serverCredentials(); err == nil {
+		t.Error("server without a CA was configured for mtls")
+	}
+}
@@ -0,0 +1,72 @@
+// Package validate checks requests before they reach the RPC handlers.
+package validate
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Rules maps full method names to a check of their request messages.
+// Methods without a rule are not checked.
+type Rules map[string]func(req any) error
+
+// For adapts a check of one request type to a rule.
+func For[T any](check func(req T) error) func(any) error {
+	return func(req any) error {
+		r, ok := req.(T)
+		if !ok {
+			return status.Errorf(codes.Internal, "validate: unexpected request type %T", req)
+		}
+		return check(r)
+	}
+}
+
+// UnaryServerInterceptor rejects requests that fail their rule. A rule's
+// status error is returned as is, so it can carry details; any other error
+// becomes INVALID_ARGUMENT.
+func UnaryServerInterceptor(rules Rules) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if rule, ok := rules[info.FullMethod]; ok {
+			if err := rule(req); err != nil {
+				return nil, invalid(err)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor checks every message the client sends as the
+// handler receives it.
+func StreamServerInterceptor(rules Rules) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if rule, ok := rules[info.FullMethod]; ok {
+			ss = &validatingStream{ServerStream: ss, rule: rule}
+		}
+		return handler(srv, ss)
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	rule func(any) error
+}
+
+func (s *validatingStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if err := s.rule(m); err != nil {
+		return invalid(err)
+	}
+	return nil
+}
+
+func invalid(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
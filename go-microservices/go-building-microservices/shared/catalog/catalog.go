@@ -0,0 +1,143 @@
+// Package catalog holds the product catalogue that the HTTP and gRPC
+// product services each serve from their own store.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+var ErrNotFound = errors.New("product not found")
+
+type Product struct {
+	ID         int
+	Name       string
+	USDPerUnit float64
+	Unit       string
+}
+
+// FieldError reports a product field that failed validation. Field uses
+// the name clients send, such as "usdPerUnit".
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Field, e.Reason)
+}
+
+// Validate checks the fields a client may set. It returns a *FieldError
+// for the first field that is wrong.
+func (p Product) Validate() error {
+	switch {
+	case p.Name == "":
+		return &FieldError{Field: "name", Reason: "must not be empty"}
+	case p.Unit == "":
+		return &FieldError{Field: "unit", Reason: "must not be empty"}
+	case math.IsNaN(p.USDPerUnit) || math.IsInf(p.USDPerUnit, 0) || p.USDPerUnit < 0:
+		return &FieldError{Field: "usdPerUnit", Reason: "must be a non-negative amount"}
+	}
+	return nil
+}
+
+// Store keeps the catalogue in memory, guarded by a RWMutex. It is safe
+// for concurrent use.
+type Store struct {
+	mu       sync.RWMutex
+	products map[int]Product
+	nextID   int
+	watchers map[*Watcher]struct{}
+}
+
+// NewStore returns a store holding seed. New products are numbered after
+// the highest seeded ID.
+func NewStore(seed []Product) *Store {
+	s := &Store{
+		products: make(map[int]Product, len(seed)),
+		nextID:   1,
+		watchers: make(map[*Watcher]struct{}),
+	}
+	for _, p := range seed {
+		s.products[p.ID] = p
+		s.nextID = max(s.nextID, p.ID+1)
+	}
+	return s
+}
+
+// List returns every product, ordered by ID.
+func (s *Store) List() []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list()
+}
+
+func (s *Store) list() []Product {
+	result := make([]Product, 0, len(s.products))
+	for _, p := range s.products {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+func (s *Store) Get(id int) (Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.products[id]
+	if !ok {
+		return Product{}, ErrNotFound
+	}
+	return p, nil
+}
+
+// Create validates p, assigns it the next free ID and stores it.
+func (s *Store) Create(p Product) (Product, error) {
+	if err := p.Validate(); err != nil {
+		return Product{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.ID = s.nextID
+	s.nextID++
+	s.products[p.ID] = p
+	s.publish(Change{Kind: Created, Product: p})
+	return p, nil
+}
+
+// Update loads the product with the given ID, hands it to fn and stores
+// the result if it is still valid. The store is locked for the duration
+// of fn, so concurrent partial updates cannot lose each other's changes.
+func (s *Store) Update(id int, fn func(p *Product) error) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.products[id]
+	if !ok {
+		return Product{}, ErrNotFound
+	}
+	if err := fn(&p); err != nil {
+		return Product{}, err
+	}
+	p.ID = id
+	if err := p.Validate(); err != nil {
+		return Product{}, err
+	}
+	s.products[id] = p
+	s.publish(Change{Kind: Updated, Product: p})
+	return p, nil
+}
+
+func (s *Store) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.products[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.products, id)
+	s.publish(Change{Kind: Deleted, Product: p})
+	return nil
+}
@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+)
+
+// ResourceType identifies products in ResourceInfo error details.
+const ResourceType = "product.Product"
+
+// Status converts an error from the store, or from the context of the call
+// that produced it, into a gRPC status error. id is the product the call
+// was about, or 0.
+//
+// Not found errors carry an errdetails.ResourceInfo and validation errors
+// an errdetails.BadRequest, so clients can act on them without parsing
+// messages. A watcher that fell behind is ABORTED, telling the client to
+// watch again. Anything unrecognised is reported as Internal.
+func Status(err error, id int) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var fe *FieldError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		st := status.New(codes.NotFound, fmt.Sprintf("product %v not found", id))
+		return withDetails(st, &errdetails.ResourceInfo{
+			ResourceType: ResourceType,
+			ResourceName: strconv.Itoa(id),
+			Description:  err.Error(),
+		})
+	case errors.As(err, &fe):
+		st := status.New(codes.InvalidArgument, fe.Error())
+		return withDetails(st, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: fe.Field, Description: fe.Reason},
+			},
+		})
+	case errors.Is(err, ErrWatcherBehind):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// withDetails attaches details to st. They are dropped only if they cannot
+// be marshalled, in which case the code and message still get through.
+func withDetails(st *status.Status, details ...protoiface.MessageV1) error {
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// FromStatus converts an error returned by the gRPC product service back
+// into the errors the store itself returns, so client code can use
+// errors.Is(err, ErrNotFound) or errors.As with *FieldError whichever
+// transport it went through. Deadline and cancellation codes become the
+// matching context errors. Other errors are returned unchanged.
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+	switch st.Code() {
+	case codes.NotFound:
+		for _, d := range st.Details() {
+			if info, ok := d.(*errdetails.ResourceInfo); ok && info.ResourceType == ResourceType {
+				return fmt.Errorf("product %v: %w", info.ResourceName, ErrNotFound)
+			}
+		}
+		return fmt.Errorf("%v: %w", st.Message(), ErrNotFound)
+	case codes.InvalidArgument:
+		for _, d := range st.Details() {
+			if br, ok := d.(*errdetails.BadRequest); ok && len(br.FieldViolations) > 0 {
+				v := br.FieldViolations[0]
+				return &FieldError{Field: v.Field, Reason: v.Description}
+			}
+		}
+	case codes.Aborted:
+		if st.Message() == ErrWatcherBehind.Error() {
+			return ErrWatcherBehind
+		}
+	case codes.DeadlineExceeded:
+		return contextError(st, context.DeadlineExceeded)
+	case codes.Canceled:
+		return contextError(st, context.Canceled)
+	}
+	return err
+}
+
+func contextError(st *status.Status, err error) error {
+	if st.Message() == err.Error() {
+		return err
+	}
+	return fmt.Errorf("%v: %w", st.Message(), err)
+}
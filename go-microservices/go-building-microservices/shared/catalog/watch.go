@@ -0,0 +1,95 @@
+package catalog
+
+import "errors"
+
+// ErrWatcherBehind ends a watch whose reader stopped keeping up with the
+// changes. Watching again starts from a fresh snapshot.
+var ErrWatcherBehind = errors.New("watcher fell behind")
+
+// watchBuffer is how many changes a watcher may have unread before it is
+// dropped.
+const watchBuffer = 64
+
+type ChangeKind int
+
+const (
+	Created ChangeKind = iota + 1
+	Updated
+	Deleted
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Created:
+		return "created"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// Change is one write to the store. Product is the new state, or the
+// last state of a deleted product.
+type Change struct {
+	Kind    ChangeKind
+	Product Product
+}
+
+// Watcher delivers the changes made to a store after Watch returned.
+type Watcher struct {
+	store   *Store
+	changes chan Change
+	err     error
+}
+
+// Watch returns the products in the store, ordered by ID, and a Watcher
+// for every change made after that snapshot. Nothing can change between
+// the two, so applying the changes to the snapshot tracks the store
+// exactly.
+//
+// Writers never wait for watchers: one that lets watchBuffer changes pile
+// up is dropped, and its channel closed with Err returning
+// ErrWatcherBehind. Call Stop when done.
+func (s *Store) Watch() ([]Product, *Watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := &Watcher{store: s, changes: make(chan Change, watchBuffer)}
+	s.watchers[w] = struct{}{}
+	return s.list(), w
+}
+
+// Changes is closed once the watcher is stopped or dropped.
+func (w *Watcher) Changes() <-chan Change {
+	return w.changes
+}
+
+// Err reports why Changes was closed: ErrWatcherBehind, or nil after Stop.
+// It must only be called once Changes has been closed.
+func (w *Watcher) Err() error {
+	return w.err
+}
+
+func (w *Watcher) Stop() {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	if _, ok := w.store.watchers[w]; ok {
+		delete(w.store.watchers, w)
+		close(w.changes)
+	}
+}
+
+// publish hands c to every watcher. The caller holds s.mu for writing,
+// which keeps changes in the order they were made.
+func (s *Store) publish(c Change) {
+	for w := range s.watchers {
+		select {
+		case w.changes <- c:
+		default:
+			w.err = ErrWatcherBehind
+			delete(s.watchers, w)
+			close(w.changes)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// GRPC adapts srv so a Supervisor can run it alongside HTTP servers.
+// Shutdown is a graceful stop: it waits for in-flight RPCs, including
+// streams, to finish.
+func GRPC(srv *grpc.Server) Server {
+	return grpcServer{srv}
+}
+
+type grpcServer struct {
+	srv *grpc.Server
+}
+
+func (g grpcServer) Serve(l net.Listener) error {
+	err := g.srv.Serve(l)
+	if err == nil || errors.Is(err, grpc.ErrServerStopped) {
+		return http.ErrServerClosed
+	}
+	return err
+}
+
+func (g grpcServer) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g grpcServer) Close() error {
+	g.srv.Stop()
+	return nil
+}
@@ -0,0 +1,198 @@
+// Package lifecycle runs several servers in one process and shuts them
+// down together.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is how long in-flight requests get to finish
+// once shutdown begins.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Supervisor starts a set of servers, waits for a signal or for one of
+// them to fail, and then drains all of them in parallel.
+//
+//	sup := lifecycle.New()
+//	sup.Add("customer", cs)
+//	sup.Add("product", ps)
+//	sup.OnStarted(func() { reg.Register(...) })
+//	err := sup.Run(context.Background())
+type Supervisor struct {
+	// ShutdownTimeout bounds the drain. Servers still busy when it
+	// expires are closed, dropping their connections.
+	ShutdownTimeout time.Duration
+	// Signals that begin shutdown. Defaults to SIGINT and SIGTERM.
+	Signals []os.Signal
+
+	servers  []namedServer
+	started  []func()
+	stopping []func()
+
+	ready atomic.Bool
+	alive atomic.Bool
+}
+
+// Server is what the Supervisor runs. *http.Server satisfies it; GRPC
+// adapts a *grpc.Server. Serve must return http.ErrServerClosed once
+// Shutdown or Close has been called.
+type Server interface {
+	Serve(l net.Listener) error
+	// Shutdown stops accepting connections and waits for in-flight
+	// requests to finish, or for ctx to be done.
+	Shutdown(ctx context.Context) error
+	// Close drops every connection at once.
+	Close() error
+}
+
+type namedServer struct {
+	name string
+	addr string
+	srv  Server
+}
+
+func New() *Supervisor {
+	return &Supervisor{
+		ShutdownTimeout: DefaultShutdownTimeout,
+		Signals:         []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+}
+
+// Add registers an HTTP server to be started by Run on its Addr. name is
+// used in logs and errors.
+func (s *Supervisor) Add(name string, srv *http.Server) {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	s.AddServer(name, addr, srv)
+}
+
+// AddServer registers any other kind of server to be started by Run on
+// addr.
+func (s *Supervisor) AddServer(name, addr string, srv Server) {
+	s.servers = append(s.servers, namedServer{name: name, addr: addr, srv: srv})
+}
+
+// OnStarted registers fn to run once every server is accepting
+// connections, such as registering them with service discovery. Hooks run
+// in the order they were added.
+func (s *Supervisor) OnStarted(fn func()) {
+	s.started = append(s.started, fn)
+}
+
+// OnShutdown registers fn to run when shutdown begins, before the servers
+// drain, such as deregistering them so new traffic goes elsewhere.
+func (s *Supervisor) OnShutdown(fn func()) {
+	s.stopping = append(s.stopping, fn)
+}
+
+// Ready reports whether every server is accepting connections and
+// shutdown has not begun. It backs readiness probes: a draining process
+// should stop receiving new traffic.
+func (s *Supervisor) Ready() bool {
+	return s.ready.Load()
+}
+
+// Alive reports whether Run is serving and no server has failed. It backs
+// liveness probes.
+func (s *Supervisor) Alive() bool {
+	return s.alive.Load()
+}
+
+// Run binds every server's address, serves until ctx is done, a signal
+// arrives or a server fails, and then shuts every server down. A server
+// that cannot bind its address fails Run immediately, before anything is
+// served. Run returns nil after a clean shutdown triggered by ctx or a
+// signal.
+func (s *Supervisor) Run(ctx context.Context) error {
+	listeners := make([]net.Listener, 0, len(s.servers))
+	for _, ns := range s.servers {
+		l, err := net.Listen("tcp", ns.addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return fmt.Errorf("%v server: %w", ns.name, err)
+		}
+		listeners = append(listeners, l)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, s.Signals...)
+	defer stop()
+
+	failed := make(chan error, len(s.servers))
+	for i, ns := range s.servers {
+		log.Printf("Starting %v server on %v", ns.name, listeners[i].Addr())
+		go func(ns namedServer, l net.Listener) {
+			if err := ns.srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+				failed <- fmt.Errorf("%v server: %w", ns.name, err)
+			}
+		}(ns, listeners[i])
+	}
+	s.alive.Store(true)
+	s.ready.Store(true)
+	for _, fn := range s.started {
+		fn()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Print("Shutting down")
+	case runErr = <-failed:
+		s.alive.Store(false)
+		log.Printf("Shutting down: %v", runErr)
+	}
+	s.ready.Store(false)
+	for _, fn := range s.stopping {
+		fn()
+	}
+
+	errs := []error{runErr}
+	errs = append(errs, s.shutdown()...)
+	s.alive.Store(false)
+	return errors.Join(errs...)
+}
+
+// shutdown drains every server in parallel, closing any that are still
+// busy when ShutdownTimeout expires.
+func (s *Supervisor) shutdown() []error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for _, ns := range s.servers {
+		wg.Add(1)
+		go func(ns namedServer) {
+			defer wg.Done()
+			err := ns.srv.Shutdown(ctx)
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = ns.srv.Close()
+				if err == nil {
+					err = fmt.Errorf("drain timed out after %v", s.ShutdownTimeout)
+				}
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%v server: %w", ns.name, err))
+				mu.Unlock()
+			}
+		}(ns)
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// ServerMetrics records the RPCs a server handles, labelled by service,
+// method and status code. Unary calls and streams share the same series;
+// streams also count the messages they carry.
+type ServerMetrics struct {
+	handled  *CounterVec
+	duration *HistogramVec
+	inFlight *GaugeVec
+	received *CounterVec
+	sent     *CounterVec
+}
+
+func NewServerMetrics(reg *Registry) *ServerMetrics {
+	m := &ServerMetrics{
+		handled:  NewCounterVec("grpc_server_handled_total", "RPCs completed, by service, method and status code.", "grpc_service", "grpc_method", "grpc_code"),
+		duration: NewHistogramVec("grpc_server_handling_seconds", "Time taken to handle an RPC.", nil, "grpc_service", "grpc_method"),
+		inFlight: NewGaugeVec("grpc_server_in_flight", "RPCs currently being handled.", "grpc_service", "grpc_method"),
+		received: NewCounterVec("grpc_server_msg_received_total", "Stream messages received from clients.", "grpc_service", "grpc_method"),
+		sent:     NewCounterVec("grpc_server_msg_sent_total", "Stream messages sent to clients.", "grpc_service", "grpc_method"),
+	}
+	reg.Register(m.handled)
+	reg.Register(m.duration)
+	reg.Register(m.inFlight)
+	reg.Register(m.received)
+	reg.Register(m.sent)
+	return m
+}
+
+func (m *ServerMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		service, method := splitMethod(info.FullMethod)
+		done := m.start(service, method)
+		res, err := handler(ctx, req)
+		done(err)
+		return res, err
+	}
+}
+
+func (m *ServerMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method := splitMethod(info.FullMethod)
+		done := m.start(service, method)
+		err := handler(srv, &countingStream{ServerStream: ss, m: m, service: service, method: method})
+		done(err)
+		return err
+	}
+}
+
+// start marks an RPC in flight and returns the function that records how
+// it ended.
+func (m *ServerMetrics) start(service, method string) func(err error) {
+	m.inFlight.Inc(service, method)
+	start := time.Now()
+	return func(err error) {
+		m.inFlight.Dec(service, method)
+		m.handled.Inc(service, method, status.Code(err).String())
+		m.duration.Observe(time.Since(start).Seconds(), service, method)
+	}
+}
+
+type countingStream struct {
+	grpc.ServerStream
+	m               *ServerMetrics
+	service, method string
+}
+
+func (s *countingStream) SendMsg(msg any) error {
+	err := s.ServerStream.SendMsg(msg)
+	if err == nil {
+		s.m.sent.Inc(s.service, s.method)
+	}
+	return err
+}
+
+func (s *countingStream) RecvMsg(msg any) error {
+	err := s.ServerStream.RecvMsg(msg)
+	if err == nil {
+		s.m.received.Inc(s.service, s.method)
+	}
+	return err
+}
+
+// splitMethod splits "/package.Service/Method".
+func splitMethod(fullMethod string) (service, method string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return service, method
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"shared/middleware"
+)
+
+// Middleware records the requests a service handles, labelled by the
+// route pattern the Router matched rather than the raw path, so that
+// /products/1 and /products/2 count towards the same series. Requests that
+// did not come through a Router are labelled "unrouted".
+func Middleware(reg *Registry) middleware.Middleware {
+	requests := NewCounterVec("http_requests_total", "Requests handled, by method, route and status code.", "method", "route", "status")
+	duration := NewHistogramVec("http_request_duration_seconds", "Time taken to handle a request.", nil, "method", "route", "status")
+	inFlight := NewGaugeVec("http_requests_in_flight", "Requests currently being handled.", "method", "route")
+	reg.Register(requests)
+	reg.Register(duration)
+	reg.Register(inFlight)
+
+	return middleware.New("metrics", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := middleware.Route(r)
+			if route == "" {
+				route = "unrouted"
+			}
+			method := normalizeMethod(r.Method)
+
+			inFlight.Inc(method, route)
+			defer inFlight.Dec(method, route)
+
+			sw := middleware.NewStatusWriter(w)
+			start := time.Now()
+			next.ServeHTTP(sw, r)
+
+			status := strconv.Itoa(sw.Status())
+			requests.Inc(method, route, status)
+			duration.Observe(time.Since(start).Seconds(), method, route, status)
+		})
+	})
+}
+
+// normalizeMethod folds unknown methods together, since clients control
+// the method and could otherwise create any number of series.
+func normalizeMethod(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
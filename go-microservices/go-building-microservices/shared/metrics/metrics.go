@@ -0,0 +1,308 @@
+// Package metrics collects counters, gauges and histograms and exposes
+// them in the Prometheus text format, without depending on the Prometheus
+// client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets suit request latencies in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector writes one or more metric families in the text format.
+type Collector interface {
+	Name() string
+	Collect(w io.Writer)
+}
+
+// Registry is the set of collectors one endpoint exposes.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// Register adds c. It panics if a collector with the same name is
+// already registered, since the exposition would then be ambiguous.
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.collectors[c.Name()]; dup {
+		panic(fmt.Sprintf("metrics: duplicate collector %q", c.Name()))
+	}
+	r.collectors[c.Name()] = c
+}
+
+// Write writes every collector, sorted by name.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]Collector, len(names))
+	for i, name := range names {
+		collectors[i] = r.collectors[name]
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.Collect(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry for scraping.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := r.Write(w); err != nil {
+			log.Print(err)
+		}
+	})
+}
+
+// vec holds one series per combination of label values.
+type vec[S any] struct {
+	name, help, typ string
+	labels          []string
+
+	mu     sync.Mutex
+	series map[string]*S
+	values map[string][]string
+}
+
+func newVec[S any](name, help, typ string, labels []string) vec[S] {
+	return vec[S]{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: make(map[string]*S),
+		values: make(map[string][]string),
+	}
+}
+
+func (v *vec[S]) Name() string {
+	return v.name
+}
+
+// with returns the series for labelValues, creating it with init. The
+// caller must hold v.mu.
+func (v *vec[S]) with(labelValues []string, init func() *S) *S {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %v wants %d label values, got %d", v.name, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = init()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), labelValues...)
+	}
+	return s
+}
+
+// each calls fn for every series in a stable order. The caller must hold
+// v.mu.
+func (v *vec[S]) each(fn func(labelValues []string, s *S)) {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fn(v.values[k], v.series[k])
+	}
+}
+
+func (v *vec[S]) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %v %v\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %v %v\n", v.name, v.typ)
+}
+
+// CounterVec is a family of counters that only go up.
+type CounterVec struct {
+	vec[float64]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec[float64](name, help, "counter", labels)}
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter by delta, which must not be negative.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.with(labelValues, newFloat) += delta
+}
+
+func (c *CounterVec) Collect(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	c.each(func(lv []string, v *float64) {
+		writeSample(w, c.name, c.labels, lv, *v)
+	})
+}
+
+// GaugeVec is a family of values that go up and down.
+type GaugeVec struct {
+	vec[float64]
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec[float64](name, help, "gauge", labels)}
+}
+
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues, newFloat) = value
+}
+
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues, newFloat) += delta
+}
+
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *GaugeVec) Collect(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	g.each(func(lv []string, v *float64) {
+		writeSample(w, g.name, g.labels, lv, *v)
+	})
+}
+
+func newFloat() *float64 {
+	return new(float64)
+}
+
+// HistogramVec is a family of histograms with cumulative buckets.
+type HistogramVec struct {
+	vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram family with the given upper bucket
+// bounds, which must be sorted. A nil buckets uses DefaultBuckets.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %v buckets are not sorted", name))
+	}
+	return &HistogramVec{vec: newVec[histogram](name, help, "histogram", labels), buckets: buckets}
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.with(labelValues, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	})
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) Collect(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	labels := append(append([]string(nil), h.labels...), "le")
+	h.each(func(lv []string, s *histogram) {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", labels, append(lv[:len(lv):len(lv)], formatFloat(bound)), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", labels, append(lv[:len(lv):len(lv)], "+Inf"), float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, lv, s.sum)
+		writeSample(w, h.name+"_count", h.labels, lv, float64(s.count))
+	})
+}
+
+func writeSample(w io.Writer, name string, labels, values []string, v float64) {
+	io.WriteString(w, name)
+	if len(labels) > 0 {
+		io.WriteString(w, "{")
+		for i, l := range labels {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			fmt.Fprintf(w, "%v=\"%v\"", l, escapeLabel(values[i]))
+		}
+		io.WriteString(w, "}")
+	}
+	fmt.Fprintf(w, " %v\n", formatFloat(v))
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// RuntimeCollector reports the Go runtime's goroutines, memory and garbage
+// collection, using the names the Prometheus Go client uses so existing
+// dashboards work unchanged.
+type RuntimeCollector struct {
+	start time.Time
+}
+
+func NewRuntimeCollector() *RuntimeCollector {
+	return &RuntimeCollector{start: time.Now()}
+}
+
+func (c *RuntimeCollector) Name() string {
+	return "go_runtime"
+}
+
+func (c *RuntimeCollector) Collect(w io.Writer) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	gauge(w, "go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	fmt.Fprintf(w, "# HELP go_info Information about the Go environment.\n# TYPE go_info gauge\ngo_info{version=\"%v\"} 1\n", escapeLabel(runtime.Version()))
+	gauge(w, "go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(ms.Alloc))
+	counter(w, "go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(ms.TotalAlloc))
+	gauge(w, "go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(ms.Sys))
+	gauge(w, "go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(ms.HeapInuse))
+	gauge(w, "go_memstats_heap_objects", "Number of allocated objects.", float64(ms.HeapObjects))
+	counter(w, "go_memstats_mallocs_total", "Total number of mallocs.", float64(ms.Mallocs))
+	counter(w, "go_memstats_frees_total", "Total number of frees.", float64(ms.Frees))
+	gcDuration(w)
+	gauge(w, "process_start_time_seconds", "Start time of the process since unix epoch in seconds.", float64(c.start.Unix()))
+}
+
+// gcDuration writes the GC pause summary as the Prometheus Go client does:
+// the minimum, quartiles and maximum of the recent pauses, with the total
+// pause time and number of collections as its sum and count.
+func gcDuration(w io.Writer) {
+	stats := debug.GCStats{PauseQuantiles: make([]time.Duration, 5)}
+	debug.ReadGCStats(&stats)
+
+	const name = "go_gc_duration_seconds"
+	fmt.Fprintf(w, "# HELP %v A summary of the pause duration of garbage collection cycles.\n# TYPE %v summary\n", name, name)
+	for i, q := range stats.PauseQuantiles {
+		fmt.Fprintf(w, "%v{quantile=\"%v\"} %v\n", name, formatFloat(float64(i)/4), formatFloat(q.Seconds()))
+	}
+	fmt.Fprintf(w, "%v_sum %v\n%v_count %v\n", name, formatFloat(stats.PauseTotal.Seconds()), name, stats.NumGC)
+}
+
+func gauge(w io.Writer, name, help string, v float64) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v gauge\n%v %v\n", name, help, name, name, formatFloat(v))
+}
+
+func counter(w io.Writer, name, help string, v float64) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v counter\n%v %v\n", name, help, name, name, formatFloat(v))
+}
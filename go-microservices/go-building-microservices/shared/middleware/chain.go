@@ -0,0 +1,159 @@
+// Package middleware composes http.Handlers out of named, reusable
+// wrappers, so services declare their global and per-route stacks instead
+// of nesting hand-written structs.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Middleware is a named wrapper around an http.Handler. Wrap must call
+// next to pass the request on, or write a response itself to stop it.
+type Middleware struct {
+	Name string
+	Wrap func(next http.Handler) http.Handler
+}
+
+// New names a plain func(http.Handler) http.Handler.
+func New(name string, wrap func(next http.Handler) http.Handler) Middleware {
+	return Middleware{Name: name, Wrap: wrap}
+}
+
+// Chain returns a handler that passes each request through mws in the
+// order given, the first being the outermost, and finally to h. A
+// middleware's code after next.ServeHTTP therefore runs in reverse order.
+//
+// Chain panics if h is nil or if two middlewares share a name, since both
+// are wiring mistakes that would otherwise only show up at request time.
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	if h == nil {
+		panic("middleware: Chain called with a nil handler")
+	}
+	seen := make(map[string]bool, len(mws))
+	for _, m := range mws {
+		if m.Name == "" || m.Wrap == nil {
+			panic("middleware: middleware must have a name and a Wrap func")
+		}
+		if seen[m.Name] {
+			panic(fmt.Sprintf("middleware: %q appears twice in the same chain", m.Name))
+		}
+		seen[m.Name] = true
+	}
+
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = record(mws[i].Name, mws[i].Wrap(h))
+	}
+	return &chain{Handler: h, names: names(mws)}
+}
+
+type chain struct {
+	http.Handler
+	names []string
+}
+
+// Names lists the middlewares h was built from, outermost first. It
+// returns nil for handlers that did not come from Chain or a Router.
+func Names(h http.Handler) []string {
+	if c, ok := h.(*chain); ok {
+		return append([]string(nil), c.names...)
+	}
+	return nil
+}
+
+func names(mws []Middleware) []string {
+	result := make([]string, len(mws))
+	for i, m := range mws {
+		result[i] = m.Name
+	}
+	return result
+}
+
+// Router is a ServeMux with a global middleware stack that every route
+// runs through before its own stack.
+type Router struct {
+	mux    *http.ServeMux
+	global []Middleware
+}
+
+func NewRouter(global ...Middleware) *Router {
+	return &Router{
+		mux:    http.NewServeMux(),
+		global: global,
+	}
+}
+
+// Handle registers h for pattern behind the global stack followed by mws.
+func (rt *Router) Handle(pattern string, h http.Handler, mws ...Middleware) {
+	all := make([]Middleware, 0, len(rt.global)+len(mws)+1)
+	all = append(all, withRoute(pattern))
+	all = append(all, rt.global...)
+	all = append(all, mws...)
+	rt.mux.Handle(pattern, Chain(h, all...))
+}
+
+func (rt *Router) HandleFunc(pattern string, h http.HandlerFunc, mws ...Middleware) {
+	rt.Handle(pattern, h, mws...)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
+
+type routeKey struct{}
+
+// withRoute stores the pattern the request matched, so middlewares can
+// report on routes rather than raw paths.
+func withRoute(pattern string) Middleware {
+	return New("route", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, pattern)))
+		})
+	})
+}
+
+// Route returns the pattern the Router matched for r, or "" outside a
+// Router.
+func Route(r *http.Request) string {
+	pattern, _ := r.Context().Value(routeKey{}).(string)
+	return pattern
+}
+
+// Recorder collects the names of the middlewares a request passes
+// through, in the order they are entered. Attach one with WithRecorder to
+// check the ordering of a chain:
+//
+//	rec := &middleware.Recorder{}
+//	h.ServeHTTP(w, middleware.WithRecorder(r, rec))
+//	rec.Names() // [route logging validation]
+type Recorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (rec *Recorder) Names() []string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]string(nil), rec.names...)
+}
+
+type recorderKey struct{}
+
+func WithRecorder(r *http.Request, rec *Recorder) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), recorderKey{}, rec))
+}
+
+// record notes name on the request's Recorder, if any, before handing the
+// request to h.
+func record(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rec, ok := r.Context().Value(recorderKey{}).(*Recorder); ok {
+			rec.mu.Lock()
+			rec.names = append(rec.names, name)
+			rec.mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
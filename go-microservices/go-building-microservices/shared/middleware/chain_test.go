@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"shared/middleware"
+)
+
+// tag returns a middleware that appends its name to *trace on the way in
+// and name+" done" on the way out.
+func tag(name string, trace *[]string) middleware.Middleware {
+	return middleware.New(name, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*trace = append(*trace, name)
+			next.ServeHTTP(w, r)
+			*trace = append(*trace, name+" done")
+		})
+	})
+}
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestChainOrder(t *testing.T) {
+	var trace []string
+	h := middleware.Chain(ok, tag("a", &trace), tag("b", &trace), tag("c", &trace))
+
+	rec := &middleware.Recorder{}
+	h.ServeHTTP(httptest.NewRecorder(), middleware.WithRecorder(httptest.NewRequest("GET", "/", nil), rec))
+
+	if got, want := rec.Names(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded %v, want %v", got, want)
+	}
+	want := []string{"a", "b", "c", "c done", "b done", "a done"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("ran %v, want %v", trace, want)
+	}
+	if got := middleware.Names(h); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Names = %v", got)
+	}
+}
+
+func TestChainStops(t *testing.T) {
+	deny := middleware.New("deny", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+	})
+	var trace []string
+	h := middleware.Chain(ok, tag("a", &trace), deny, tag("b", &trace))
+
+	rec := &middleware.Recorder{}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, middleware.WithRecorder(httptest.NewRequest("GET", "/", nil), rec))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if got, want := rec.Names(), []string{"a", "deny"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded %v, want %v", got, want)
+	}
+}
+
+func TestChainPanicsOnWiringMistakes(t *testing.T) {
+	var trace []string
+	tests := map[string]func(){
+		"nil handler":    func() { middleware.Chain(nil, tag("a", &trace)) },
+		"duplicate name": func() { middleware.Chain(ok, tag("a", &trace), tag("a", &trace)) },
+		"unnamed":        func() { middleware.Chain(ok, middleware.New("", tag("a", &trace).Wrap)) },
+	}
+	for name, build := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Chain did not panic")
+				}
+			}()
+			build()
+		})
+	}
+}
+
+func TestRouterOrder(t *testing.T) {
+	var trace []string
+	rt := middleware.NewRouter(tag("global1", &trace), tag("global2", &trace))
+
+	var route string
+	rt.Handle("/carts/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route = middleware.Route(r)
+	}), tag("validation", &trace))
+	rt.Handle("/products", ok)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/carts/1", []string{"route", "global1", "global2", "validation"}},
+		{"/products", []string{"route", "global1", "global2"}},
+	}
+	for _, tt := range tests {
+		rec := &middleware.Recorder{}
+		rt.ServeHTTP(httptest.NewRecorder(), middleware.WithRecorder(httptest.NewRequest("GET", tt.path, nil), rec))
+		if got := rec.Names(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v recorded %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if route != "/carts/" {
+		t.Errorf("Route = %q, want %q", route, "/carts/")
+	}
+}
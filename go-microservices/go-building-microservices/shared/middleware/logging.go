@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	mathrand "math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader carries the request ID between services and back to the
+// client.
+const RequestIDHeader = "X-Request-ID"
+
+// DefaultRedactedHeaders are never written to the log in clear text.
+var DefaultRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization", "X-Api-Key"}
+
+type LoggingConfig struct {
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+	// SampleRate is the fraction of successful requests that are logged,
+	// from 0 to 1. Requests that end in a 4xx or 5xx are always logged.
+	SampleRate float64
+	// LogHeaders adds the request headers to each line, with the values
+	// of RedactHeaders replaced.
+	LogHeaders    bool
+	RedactHeaders []string
+	// Attrs adds attributes taken from the request context, such as a
+	// trace ID set by a middleware further out.
+	Attrs func(ctx context.Context) []slog.Attr
+}
+
+func DefaultLoggingConfig() LoggingConfig {
+	return LoggingConfig{
+		SampleRate:    1,
+		RedactHeaders: DefaultRedactedHeaders,
+	}
+}
+
+// Logging assigns every request an ID and logs one structured line when
+// its response has been generated, with the status and size of the
+// response.
+func Logging(cfg LoggingConfig) Middleware {
+	redact := make(map[string]bool, len(cfg.RedactHeaders))
+	for _, h := range cfg.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return New("logging", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := cfg.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			r = r.WithContext(WithRequestID(r.Context(), id))
+
+			sw := NewStatusWriter(w)
+			now := time.Now()
+
+			next.ServeHTTP(sw, r)
+
+			status := sw.Status()
+			if status < http.StatusBadRequest && mathrand.Float64() >= cfg.SampleRate {
+				return
+			}
+
+			attrs := []slog.Attr{
+				slog.String("requestId", id),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", Route(r)),
+				slog.Int("status", status),
+				slog.Int64("bytes", sw.Bytes()),
+				slog.Duration("duration", time.Since(now)),
+				slog.String("remoteAddr", r.RemoteAddr),
+			}
+			if cfg.Attrs != nil {
+				attrs = append(attrs, cfg.Attrs(r.Context())...)
+			}
+			if cfg.LogHeaders {
+				attrs = append(attrs, headerAttrs(r.Header, redact))
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(r.Context(), level, "Response generated", attrs...)
+		})
+	})
+}
+
+func headerAttrs(h http.Header, redact map[string]bool) slog.Attr {
+	values := make([]any, 0, len(h))
+	for name, v := range h {
+		value := strings.Join(v, ", ")
+		if redact[name] {
+			value = "[REDACTED]"
+		}
+		values = append(values, slog.String(name, value))
+	}
+	return slog.Group("headers", values...)
+}
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID Logging assigned to the request that ctx
+// belongs to, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts incoming IDs that are safe to echo into headers
+// and logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDTransport copies the request ID from each outgoing request's
+// context into its headers, so the next service logs the same ID. A nil
+// next uses http.DefaultTransport.
+func RequestIDTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if id := RequestID(r.Context()); id != "" && r.Header.Get(RequestIDHeader) == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set(RequestIDHeader, id)
+		}
+		return next.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,167 @@
+// Package problem reports errors to clients as RFC 7807 problem details,
+// served as application/problem+json.
+//
+// Handlers return errors instead of writing status codes themselves. An
+// *Error carries the status and the message meant for the client; any
+// other error is logged and answered with a generic 500, so internal
+// details never leak into responses.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"shared/middleware"
+)
+
+const ContentType = "application/problem+json"
+
+// Details is the body of a problem response. Extensions are written as
+// additional top-level members.
+type Details struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	RequestID  string
+	Extensions map[string]any
+}
+
+func (d Details) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(d.Extensions)+6)
+	for k, v := range d.Extensions {
+		m[k] = v
+	}
+	m["type"] = d.Type
+	m["title"] = d.Title
+	m["status"] = d.Status
+	if d.Detail != "" {
+		m["detail"] = d.Detail
+	}
+	if d.Instance != "" {
+		m["instance"] = d.Instance
+	}
+	if d.RequestID != "" {
+		m["requestId"] = d.RequestID
+	}
+	return json.Marshal(m)
+}
+
+// Error is an error with a status code and a message for the client.
+type Error struct {
+	Status int
+	// Type is a URI identifying the kind of problem. It defaults to
+	// "about:blank", meaning the status code says it all.
+	Type string
+	// Title defaults to the status text.
+	Title      string
+	Detail     string
+	Extensions map[string]any
+	// Err is the underlying cause, if any. It is not sent to the client.
+	Err error
+}
+
+// New returns an Error with a formatted detail message.
+func New(status int, format string, args ...any) *Error {
+	return &Error{Status: status, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Wrap returns an Error whose detail is err's message.
+func Wrap(status int, err error) *Error {
+	return &Error{Status: status, Detail: err.Error(), Err: err}
+}
+
+func BadRequest(format string, args ...any) *Error {
+	return New(http.StatusBadRequest, format, args...)
+}
+
+func NotFound(r *http.Request) *Error {
+	return New(http.StatusNotFound, "%v not found", r.URL.Path)
+}
+
+func MethodNotAllowed(r *http.Request) *Error {
+	return New(http.StatusMethodNotAllowed, "method %v is not allowed on %v", r.Method, r.URL.Path)
+}
+
+// With adds an extension member to the problem and returns e.
+func (e *Error) With(key string, value any) *Error {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any)
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%d %v: %v", e.Status, http.StatusText(e.Status), e.Detail)
+	}
+	return fmt.Sprintf("%d %v", e.Status, http.StatusText(e.Status))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HandlerFunc is a handler that reports failure by returning an error,
+// which is written with Write. It must not have written a response when
+// it returns a non-nil error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		Write(w, r, err)
+	}
+}
+
+// NotFoundHandler answers every request with a 404 problem. Register it
+// on "/" so unknown paths get the same kind of body as everything else.
+func NotFoundHandler() http.Handler {
+	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return NotFound(r)
+	})
+}
+
+// Write answers r with the problem err describes. An error that is not,
+// and does not wrap, an *Error is logged and reported as a 500 without
+// its message.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+	if e.Status >= http.StatusInternalServerError {
+		log.Print(err)
+	}
+
+	d := Details{
+		Type:       e.Type,
+		Title:      e.Title,
+		Status:     e.Status,
+		Detail:     e.Detail,
+		Instance:   r.URL.Path,
+		RequestID:  middleware.RequestID(r.Context()),
+		Extensions: e.Extensions,
+	}
+	if d.Type == "" {
+		d.Type = "about:blank"
+	}
+	if d.Title == "" {
+		d.Title = http.StatusText(e.Status)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	w.Write(data)
+}
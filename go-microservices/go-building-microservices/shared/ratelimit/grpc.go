@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RPCKeyFunc identifies the client an RPC counts against, as KeyFunc
+// does for HTTP requests.
+type RPCKeyFunc func(ctx context.Context) string
+
+// ByPeer keys RPCs by the address of the connection.
+func ByPeer(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+// UnaryServerInterceptor enforces policies, keyed by full method name
+// such as "/productService.Product/GetProduct". Methods without a policy
+// are not limited. The client's standing is sent in ratelimit-* header
+// metadata, and RPCs over the limit fail with ResourceExhausted and a
+// retry-after entry. It panics if a policy does not validate.
+func UnaryServerInterceptor(store Store, key RPCKeyFunc, policies map[string]Policy) grpc.UnaryServerInterceptor {
+	if key == nil {
+		key = ByPeer
+	}
+	for _, p := range policies {
+		p.mustValidate()
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		p, ok := policies[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+		res, err := store.Take(key(ctx), p, time.Now())
+		if err != nil {
+			log.Print(err)
+			return handler(ctx, req)
+		}
+
+		md := metadata.Pairs(
+			"ratelimit-policy", p.String(),
+			"ratelimit-limit", strconv.Itoa(res.Limit),
+			"ratelimit-remaining", strconv.Itoa(res.Remaining),
+			"ratelimit-reset", ceilSeconds(res.Reset),
+		)
+		if !res.Allowed {
+			md.Set("retry-after", ceilSeconds(res.RetryAfter))
+			grpc.SetHeader(ctx, md)
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limit of %v requests per %v exceeded", p.Limit, p.Window)
+		}
+		grpc.SetHeader(ctx, md)
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// headerStream records the header metadata a handler sets.
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	const limited, unlimited = "/test.Service/Limited", "/test.Service/Unlimited"
+	interceptor := UnaryServerInterceptor(NewMemoryStore(), func(ctx context.Context) string { return "a" }, map[string]Policy{
+		limited: {Name: "test", Limit: 1, Window: time.Minute},
+	})
+
+	call := func(method string) (metadata.MD, error) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		})
+		return stream.header, err
+	}
+
+	md, err := call(limited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := md.Get("ratelimit-remaining"); len(got) != 1 || got[0] != "0" {
+		t.Errorf("ratelimit-remaining = %v, want 0", got)
+	}
+
+	md, err = call(limited)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("over the limit: error %v, want ResourceExhausted", err)
+	}
+	if got := md.Get("retry-after"); len(got) != 1 || got[0] != "60" {
+		t.Errorf("retry-after = %v, want 60", got)
+	}
+
+	if md, err := call(unlimited); err != nil || md.Len() != 0 {
+		t.Errorf("method without a policy: error %v, metadata %v", err, md)
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shared/middleware"
+	"shared/problem"
+)
+
+// KeyFunc identifies the client a request counts against.
+type KeyFunc func(r *http.Request) string
+
+// ByIP keys requests by the address of the connection. Forwarding headers
+// are ignored, since any client can set them.
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Internal keys requests that carry token in header as "internal", so
+// calls between services get a budget of their own rather than sharing
+// one with every client at the same address. Other requests are keyed by
+// fallback. The token is compared in constant time; an empty token
+// matches nothing.
+func Internal(header, token string, fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(header); v != "" && subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1 {
+			return "internal"
+		}
+		return fallback(r)
+	}
+}
+
+// Limiter applies policies to requests.
+type Limiter struct {
+	Store Store
+	// Key defaults to ByIP. A KeyFunc that reads the authenticated user
+	// from the request context limits per user instead.
+	Key KeyFunc
+}
+
+func NewLimiter(store Store, key KeyFunc) *Limiter {
+	if key == nil {
+		key = ByIP
+	}
+	return &Limiter{Store: store, Key: key}
+}
+
+// Middleware enforces p. It reports the client's standing in RateLimit-*
+// headers on every response and refuses requests over the limit with a
+// 429 and Retry-After. Attach it to the routes p covers:
+//
+//	router.Handle("/carts", h, limiter.Middleware(cartsPolicy))
+//
+// When the store fails the request is let through, since refusing all
+// traffic is worse than briefly not limiting it. Middleware panics if p
+// does not validate.
+func (l *Limiter) Middleware(p Policy) middleware.Middleware {
+	p.mustValidate()
+	return middleware.New("ratelimit", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := l.Store.Take(l.Key(r), p, time.Now())
+			if err != nil {
+				log.Print(err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", p.String())
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				problem.Write(w, r, problem.New(http.StatusTooManyRequests, "rate limit of %v requests per %v exceeded", p.Limit, p.Window))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	p := Policy{Name: "carts", Limit: 1, Window: time.Minute}
+	limiter := NewLimiter(NewMemoryStore(), Internal("X-Internal-Token", "secret", ByIP))
+	h := limiter.Middleware(p).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	get := func(remoteAddr, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/carts", nil)
+		r.RemoteAddr = remoteAddr
+		if token != "" {
+			r.Header.Set("X-Internal-Token", token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("10.0.0.1:1234", "")
+	want := map[string]string{
+		"RateLimit-Policy":    "1;w=60",
+		"RateLimit-Limit":     "1",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "60",
+	}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%v = %q, want %q", name, got, value)
+		}
+	}
+	if w.Code != http.StatusOK || w.Header().Get("Retry-After") != "" {
+		t.Errorf("first request: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Another port is the same client.
+	w = get("10.0.0.1:5678", "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("over the limit: status %d, Retry-After %q, want 429 after 60s", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// The internal token has a budget of its own; a wrong one does not.
+	if w := get("10.0.0.1:1234", "secret"); w.Code != http.StatusOK {
+		t.Errorf("internal request: status %d", w.Code)
+	}
+	if w := get("10.0.0.1:1234", "guess"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("request with a wrong token: status %d", w.Code)
+	}
+}
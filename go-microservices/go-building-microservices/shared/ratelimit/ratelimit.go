@@ -0,0 +1,123 @@
+// Package ratelimit limits how often each client may call a route, with a
+// token bucket per client and policy.
+//
+// A bucket holds up to Policy.Limit tokens and refills at Limit tokens per
+// Policy.Window. Every request takes one token; a request that finds the
+// bucket empty is refused until a token has refilled. Bucket state lives
+// in a Store, so several instances can share it.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Policy is a named limit. Buckets are kept per policy, so a client's
+// use of one route does not count against another.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Validate reports a policy that cannot be enforced: one that allows no
+// requests or whose window is not positive.
+func (p Policy) Validate() error {
+	switch {
+	case p.Limit <= 0:
+		return fmt.Errorf("ratelimit: policy %q: limit must be positive", p.Name)
+	case p.Window <= 0:
+		return fmt.Errorf("ratelimit: policy %q: window must be positive", p.Name)
+	}
+	return nil
+}
+
+// mustValidate panics if p is invalid. Policies are fixed when routes are
+// set up, so a bad one is a programming error.
+func (p Policy) mustValidate() {
+	if err := p.Validate(); err != nil {
+		panic(err)
+	}
+}
+
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// String formats p as a RateLimit-Policy header value.
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(math.Ceil(p.Window.Seconds())))
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a token is available, when Allowed is
+	// false.
+	RetryAfter time.Duration
+}
+
+// Store keeps bucket state.
+type Store interface {
+	// Take removes a token from the bucket for key under p. It fails if
+	// p does not validate.
+	Take(key string, p Policy, now time.Time) (Result, error)
+}
+
+// bucket is the state of one token bucket. Tokens are refilled lazily,
+// from the time elapsed since Last.
+type bucket struct {
+	Tokens float64       `json:"tokens"`
+	Last   time.Time     `json:"last"`
+	Window time.Duration `json:"window"`
+}
+
+func newBucket(p Policy, now time.Time) *bucket {
+	return &bucket{Tokens: float64(p.Limit), Last: now, Window: p.Window}
+}
+
+func (b *bucket) take(p Policy, now time.Time) Result {
+	rate := p.rate()
+	if elapsed := now.Sub(b.Last).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(p.Limit), b.Tokens+elapsed*rate)
+		b.Last = now
+	}
+	b.Window = p.Window
+
+	res := Result{Limit: p.Limit}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.Tokens) / rate)
+	}
+	res.Remaining = int(b.Tokens)
+	res.Reset = seconds((float64(p.Limit) - b.Tokens) / rate)
+	return res
+}
+
+// full reports whether the bucket would have refilled completely by now,
+// in which case it is no different from a new one and can be dropped.
+func (b *bucket) full(now time.Time) bool {
+	return now.Sub(b.Last) >= b.Window
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ceilSeconds formats d as whole seconds, rounded up, for headers such as
+// Retry-After.
+func ceilSeconds(d time.Duration) string {
+	s := int64(d / time.Second)
+	if d%time.Second != 0 {
+		s++
+	}
+	return strconv.FormatInt(s, 10)
+}
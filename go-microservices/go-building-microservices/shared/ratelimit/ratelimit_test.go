@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// take takes a token and fails the test if the store errors.
+func take(t *testing.T, s Store, key string, p Policy, now time.Time) Result {
+	t.Helper()
+	res, err := s.Take(key, p, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestBucketRefill(t *testing.T) {
+	// Two tokens, refilling at one a second.
+	p := Policy{Name: "test", Limit: 2, Window: 2 * time.Second}
+	s := NewMemoryStore()
+
+	steps := []struct {
+		at                time.Duration
+		allowed           bool
+		remaining         int
+		reset, retryAfter time.Duration
+	}{
+		{0, true, 1, time.Second, 0},
+		{0, true, 0, 2 * time.Second, 0},
+		{0, false, 0, 2 * time.Second, time.Second},
+		{500 * time.Millisecond, false, 0, 1500 * time.Millisecond, 500 * time.Millisecond},
+		{time.Second, true, 0, 2 * time.Second, 0},
+		// Idle for longer than the window refills the bucket but no
+		// further.
+		{time.Minute, true, 1, time.Second, 0},
+	}
+	for i, step := range steps {
+		res := take(t, s, "a", p, t0.Add(step.at))
+		want := Result{Allowed: step.allowed, Limit: 2, Remaining: step.remaining, Reset: step.reset, RetryAfter: step.retryAfter}
+		if res != want {
+			t.Errorf("take %d at +%v = %+v, want %+v", i, step.at, res, want)
+		}
+	}
+}
+
+func TestBucketsAreKeptPerPolicyAndKey(t *testing.T) {
+	carts := Policy{Name: "carts", Limit: 1, Window: time.Minute}
+	products := Policy{Name: "products", Limit: 1, Window: time.Minute}
+	s := NewMemoryStore()
+
+	take(t, s, "a", carts, t0)
+	if take(t, s, "a", carts, t0).Allowed {
+		t.Error("second take under carts was allowed")
+	}
+	if !take(t, s, "a", products, t0).Allowed {
+		t.Error("using carts counted against products")
+	}
+	if !take(t, s, "b", carts, t0).Allowed {
+		t.Error("client a's use counted against client b")
+	}
+}
+
+func TestMemoryStorePrunesFullBuckets(t *testing.T) {
+	p := Policy{Name: "test", Limit: 10, Window: time.Minute}
+	s := NewMemoryStore()
+	take(t, s, "idle", p, t0)
+	take(t, s, "busy", p, t0.Add(30*time.Second))
+
+	// The sweep on the pruneEvery'th take drops only buckets that have
+	// refilled completely.
+	for i := 2; i < pruneEvery; i++ {
+		take(t, s, "busy", p, t0.Add(time.Minute))
+	}
+	if _, ok := s.buckets["test/idle"]; ok {
+		t.Error("full bucket was not pruned")
+	}
+	if _, ok := s.buckets["test/busy"]; !ok {
+		t.Error("bucket in use was pruned")
+	}
+}
+
+func TestFileStoreIsSharedBetweenStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	p := Policy{Name: "test", Limit: 1, Window: time.Minute}
+
+	if !take(t, NewFileStore(path), "a", p, t0).Allowed {
+		t.Fatal("first take was refused")
+	}
+	other := NewFileStore(path)
+	if res := take(t, other, "a", p, t0.Add(time.Second)); res.Allowed {
+		t.Error("a second store did not see the first store's take")
+	}
+	if !take(t, other, "a", p, t0.Add(time.Minute)).Allowed {
+		t.Error("bucket did not refill")
+	}
+}
+
+func TestInvalidPolicies(t *testing.T) {
+	for _, p := range []Policy{
+		{Name: "no window", Limit: 1},
+		{Name: "negative window", Limit: 1, Window: -time.Second},
+		{Name: "no limit", Window: time.Minute},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%v: Validate passed", p.Name)
+		}
+		if _, err := NewMemoryStore().Take("a", p, t0); err == nil {
+			t.Errorf("%v: MemoryStore.Take passed", p.Name)
+		}
+		if _, err := NewFileStore(filepath.Join(t.TempDir(), "limits.json")).Take("a", p, t0); err == nil {
+			t.Errorf("%v: FileStore.Take passed", p.Name)
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: Middleware did not panic", p.Name)
+				}
+			}()
+			NewLimiter(NewMemoryStore(), nil).Middleware(p)
+		}()
+	}
+}
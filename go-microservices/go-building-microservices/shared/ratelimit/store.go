@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps buckets in process memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// pruneEvery is how many takes pass between sweeps for full buckets.
+const pruneEvery = 1000
+
+func (s *MemoryStore) Take(key string, p Policy, now time.Time) (Result, error) {
+	if err := p.Validate(); err != nil {
+		return Result{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := p.Name + "/" + key
+	b, ok := s.buckets[k]
+	if !ok {
+		b = newBucket(p, now)
+		s.buckets[k] = b
+	}
+	res := b.take(p, now)
+
+	if s.takes++; s.takes%pruneEvery == 0 {
+		for k, b := range s.buckets {
+			if b.full(now) {
+				delete(s.buckets, k)
+			}
+		}
+	}
+	return res, nil
+}
+
+// FileStore keeps buckets in a JSON file, locked for every take, so that
+// several processes on one machine share limits. It suits tests of
+// multi-instance setups rather than heavy traffic, since every take
+// rewrites the file.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Take(key string, p Policy, now time.Time) (Result, error) {
+	if err := p.Validate(); err != nil {
+		return Result{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return Result{}, err
+	}
+	defer unlock()
+
+	buckets := make(map[string]*bucket)
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return Result{}, err
+	default:
+		if err := json.Unmarshal(data, &buckets); err != nil {
+			return Result{}, err
+		}
+	}
+
+	k := p.Name + "/" + key
+	b, ok := buckets[k]
+	if !ok {
+		b = newBucket(p, now)
+		buckets[k] = b
+	}
+	res := b.take(p, now)
+	for k, b := range buckets {
+		if b.full(now) {
+			delete(buckets, k)
+		}
+	}
+
+	data, err = json.Marshal(buckets)
+	if err != nil {
+		return Result{}, err
+	}
+	return res, writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic replaces path with data so readers never see a partial
+// file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,102 @@
+package trace
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor starts a server span for every RPC, continuing
+// the trace in the traceparent metadata.
+func UnaryServerInterceptor(t *Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var remote SpanContext
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			remote = extractMetadata(md)
+		}
+		ctx, span := t.Start(ctx, info.FullMethod, KindServer, remote)
+		defer span.End()
+		span.SetAttribute("rpc.system", "grpc")
+		span.SetAttribute("rpc.method", info.FullMethod)
+
+		res, err := handler(ctx, req)
+		recordStatus(span, err)
+		return res, err
+	}
+}
+
+// StreamServerInterceptor starts a server span for every stream, which
+// lasts until the handler returns. The handler sees the span in its
+// stream's context, so logs and outgoing calls join the trace.
+func StreamServerInterceptor(t *Tracer) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		var remote SpanContext
+		if md, ok := metadata.FromIncomingContext(ss.Context()); ok {
+			remote = extractMetadata(md)
+		}
+		ctx, span := t.Start(ss.Context(), info.FullMethod, KindServer, remote)
+		defer span.End()
+		span.SetAttribute("rpc.system", "grpc")
+		span.SetAttribute("rpc.method", info.FullMethod)
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		recordStatus(span, err)
+		return err
+	}
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryClientInterceptor starts a client span for every RPC and sends its
+// context in the traceparent metadata.
+func UnaryClientInterceptor(t *Tracer) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := t.Start(ctx, method, KindClient, SpanContext{})
+		defer span.End()
+		span.SetAttribute("rpc.system", "grpc")
+		span.SetAttribute("rpc.method", method)
+
+		sc := span.SpanContext()
+		ctx = metadata.AppendToOutgoingContext(ctx, TraceparentHeader, sc.Traceparent())
+		if sc.TraceState != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, TracestateHeader, sc.TraceState)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		recordStatus(span, err)
+		return err
+	}
+}
+
+func extractMetadata(md metadata.MD) SpanContext {
+	values := md.Get(TraceparentHeader)
+	if len(values) == 0 {
+		return SpanContext{}
+	}
+	sc, err := ParseTraceparent(values[0])
+	if err != nil {
+		return SpanContext{}
+	}
+	if ts := md.Get(TracestateHeader); len(ts) > 0 {
+		sc.TraceState = ts[0]
+	}
+	return sc
+}
+
+func recordStatus(span *Span, err error) {
+	code := status.Code(err)
+	span.SetAttribute("rpc.grpc.status_code", code.String())
+	if err != nil {
+		span.SetStatus(StatusError, status.Convert(err).Message())
+	}
+}
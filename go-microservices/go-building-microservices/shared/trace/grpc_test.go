@@ -0,0 +1,107 @@
+package trace
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serveHealth serves the standard health service over an in-memory
+// connection, traced by serverTracer, and returns a client traced by
+// clientTracer.
+func serveHealth(t *testing.T, serverTracer, clientTracer *Tracer) grpc_health_v1.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	// The handlers behind the tracing interceptors must see their span.
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(serverTracer), func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if FromContext(ctx) == nil {
+				t.Errorf("%v handler ran outside a span", info.FullMethod)
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(serverTracer), func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if FromContext(ss.Context()) == nil {
+				t.Errorf("%v handler ran outside a span", info.FullMethod)
+			}
+			return handler(srv, ss)
+		}),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(clientTracer)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	exporter := NewMemoryExporter()
+	clientTracer := NewTracer("client", exporter)
+	client := serveHealth(t, NewTracer("server", exporter), clientTracer)
+
+	ctx, root := clientTracer.Start(context.Background(), "root", KindInternal, SpanContext{})
+	if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "unknown"}); err == nil {
+		t.Fatal("health check of an unknown service passed")
+	}
+	root.End()
+
+	spans := byKind(t, exporter, 3)
+	rt, cli, srv := spans[KindInternal], spans[KindClient], spans[KindServer]
+	if cli.Parent != rt.SpanContext.SpanID || srv.Parent != cli.SpanContext.SpanID || srv.SpanContext.TraceID != rt.SpanContext.TraceID {
+		t.Errorf("spans do not form root -> client -> server: %+v", spans)
+	}
+	for _, s := range []SpanData{cli, srv} {
+		if s.Name != grpc_health_v1.Health_Check_FullMethodName || s.Attributes["rpc.grpc.status_code"] != "NotFound" || s.Status != StatusError {
+			t.Errorf("%v span = %v with status %v %v", s.Service, s.Name, s.Attributes["rpc.grpc.status_code"], s.Status)
+		}
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	exporter := NewMemoryExporter()
+	clientTracer := NewTracer("client", exporter)
+	client := serveHealth(t, NewTracer("server", exporter), clientTracer)
+
+	ctx, root := clientTracer.Start(context.Background(), "root", KindInternal, SpanContext{})
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(ctx, TraceparentHeader, root.SpanContext().Traceparent()))
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	root.End()
+
+	// The server span ends when the handler notices the cancellation.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(exporter.Spans()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	spans := byKind(t, exporter, 2)
+	rt, srv := spans[KindInternal], spans[KindServer]
+	if srv.Parent != rt.SpanContext.SpanID || srv.SpanContext.TraceID != rt.SpanContext.TraceID {
+		t.Errorf("stream span is not a child of the caller's span: %+v", spans)
+	}
+	if srv.Name != grpc_health_v1.Health_Watch_FullMethodName || srv.Attributes["rpc.grpc.status_code"] != "Canceled" {
+		t.Errorf("stream span = %v with status %v", srv.Name, srv.Attributes["rpc.grpc.status_code"])
+	}
+}
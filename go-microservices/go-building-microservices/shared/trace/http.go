@@ -0,0 +1,94 @@
+package trace
+
+import (
+	"net/http"
+	"strconv"
+
+	"shared/middleware"
+)
+
+// Extract reads the remote span context from h. It returns an invalid
+// SpanContext when h carries none or a malformed one.
+func Extract(h http.Header) SpanContext {
+	sc, err := ParseTraceparent(h.Get(TraceparentHeader))
+	if err != nil {
+		return SpanContext{}
+	}
+	sc.TraceState = h.Get(TracestateHeader)
+	return sc
+}
+
+// Inject writes sc to h.
+func Inject(h http.Header, sc SpanContext) {
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// Middleware starts a server span for every request, continuing the trace
+// in its traceparent header. Spans are named after the route pattern
+// rather than the path, so /products/1 and /products/2 group together. It
+// should run outside Logging so log lines can carry the trace ID.
+func Middleware(t *Tracer) middleware.Middleware {
+	return middleware.New("tracing", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := middleware.Route(r)
+			if route == "" {
+				route = r.URL.Path
+			}
+			ctx, span := t.Start(r.Context(), r.Method+" "+route, KindServer, Extract(r.Header))
+			defer span.End()
+			span.SetAttribute("http.method", r.Method)
+			span.SetAttribute("http.route", route)
+			span.SetAttribute("http.target", r.URL.RequestURI())
+
+			sw := middleware.NewStatusWriter(w)
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			status := sw.Status()
+			span.SetAttribute("http.status_code", strconv.Itoa(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(StatusError, http.StatusText(status))
+			}
+		})
+	})
+}
+
+// Transport starts a client span for every outgoing request and sends
+// its context in the traceparent header. A nil next uses
+// http.DefaultTransport.
+func Transport(t *Tracer, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		ctx, span := t.Start(r.Context(), r.Method+" "+r.URL.Host, KindClient, SpanContext{})
+		defer span.End()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.url", r.URL.String())
+
+		r = r.Clone(ctx)
+		Inject(r.Header, span.SpanContext())
+
+		res, err := next.RoundTrip(r)
+		if err != nil {
+			span.SetStatus(StatusError, err.Error())
+			return nil, err
+		}
+		span.SetAttribute("http.status_code", strconv.Itoa(res.StatusCode))
+		if res.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, res.Status)
+		}
+		return res, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
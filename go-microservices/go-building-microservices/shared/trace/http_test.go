@@ -0,0 +1,60 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareAndTransport(t *testing.T) {
+	exporter := NewMemoryExporter()
+	server := httptest.NewServer(Middleware(NewTracer("server", exporter)).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !FromContext(r.Context()).SpanContext().IsValid() {
+			t.Error("handler ran outside a span")
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	})))
+	defer server.Close()
+
+	clientTracer := NewTracer("client", exporter)
+	ctx, root := clientTracer.Start(context.Background(), "root", KindInternal, SpanContext{})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/products/1", nil)
+	res, err := (&http.Client{Transport: Transport(clientTracer, nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	root.End()
+
+	spans := byKind(t, exporter, 3)
+	rt, cli, srv := spans[KindInternal], spans[KindClient], spans[KindServer]
+	if cli.Parent != rt.SpanContext.SpanID || srv.Parent != cli.SpanContext.SpanID {
+		t.Errorf("spans do not form root -> client -> server: %+v", spans)
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID != rt.SpanContext.TraceID {
+			t.Errorf("%v span is in trace %v, want %v", s.Name, s.SpanContext.TraceID, rt.SpanContext.TraceID)
+		}
+	}
+	if srv.Kind != KindServer || srv.Service != "server" || srv.Name != "GET /products/1" {
+		t.Errorf("server span = %v %v of kind %v", srv.Service, srv.Name, srv.Kind)
+	}
+	if srv.Status != StatusError || cli.Status != StatusError || srv.Attributes["http.status_code"] != "502" {
+		t.Errorf("a 502 was not recorded as an error: server %+v, client %+v", srv, cli)
+	}
+}
+
+// byKind returns the spans exported so far by kind, failing the test
+// unless there are want of them, one of each kind.
+func byKind(t *testing.T, exporter *MemoryExporter, want int) map[SpanKind]SpanData {
+	t.Helper()
+	spans := make(map[SpanKind]SpanData)
+	for _, s := range exporter.Spans() {
+		spans[s.Kind] = s
+	}
+	if len(exporter.Spans()) != want || len(spans) != want {
+		t.Fatalf("exported %v, want %d spans of different kinds", exporter.Spans(), want)
+	}
+	return spans
+}
@@ -0,0 +1,265 @@
+// Package trace records spans and propagates them between services with
+// the W3C Trace Context traceparent header, so the work one request
+// causes across services can be assembled into a single tree.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader and TracestateHeader are the W3C Trace Context
+// headers.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+func (id SpanID) IsValid() bool   { return id != SpanID{} }
+
+// SpanContext is the part of a span that crosses process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// TraceState is passed along unchanged.
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%v-%v-%v", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a traceparent header value. Versions other than
+// 00 are accepted as long as they start with the version 00 fields, as
+// the specification asks.
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	if err := decodeHex(sc.TraceID[:], parts[1]); err != nil {
+		return sc, fmt.Errorf("invalid trace ID in %q", s)
+	}
+	if err := decodeHex(sc.SpanID[:], parts[2]); err != nil {
+		return sc, fmt.Errorf("invalid span ID in %q", s)
+	}
+	var flags [1]byte
+	if err := decodeHex(flags[:], parts[3]); err != nil {
+		return sc, fmt.Errorf("invalid flags in %q", s)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: all-zero ID", s)
+	}
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex of exactly len(dst) bytes.
+func decodeHex(dst []byte, s string) error {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return fmt.Errorf("bad length or case")
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
+
+type SpanKind int
+
+// Kinds, numbered as in OTLP.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+type StatusCode int
+
+// Status codes, numbered as in OTLP.
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// SpanData is a finished span, as handed to an Exporter.
+type SpanData struct {
+	Service       string
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanID
+	Start, End    time.Time
+	Attributes    map[string]string
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Span is an operation in progress. Its methods are safe for concurrent
+// use and do nothing on a nil Span.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = code
+	s.data.StatusMessage = message
+}
+
+// End finishes the span and exports it if it is sampled. Calls after the
+// first do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		s.tracer.exporter.Export(data)
+	}
+}
+
+// Tracer starts spans for one service.
+type Tracer struct {
+	service  string
+	exporter Exporter
+}
+
+// NewTracer returns a tracer that names spans' service and sends them to
+// exporter. A nil exporter discards them, which still propagates trace
+// context to other services.
+func NewTracer(service string, exporter Exporter) *Tracer {
+	if exporter == nil {
+		exporter = Discard
+	}
+	return &Tracer{service: service, exporter: exporter}
+}
+
+// Start begins a span as a child of the span in ctx, or of remote when ctx
+// holds none and remote is valid, or else as the root of a new trace.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, remote SpanContext) (context.Context, *Span) {
+	parent := FromContext(ctx).SpanContext()
+	if !parent.IsValid() {
+		parent = remote
+	}
+
+	sc := SpanContext{SpanID: newSpanID(), Sampled: true}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+	}
+
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			Service:     t.service,
+			Name:        name,
+			Kind:        kind,
+			SpanContext: sc,
+			Parent:      parent.SpanID,
+			Start:       time.Now(),
+			Attributes:  make(map[string]string),
+		},
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type spanKey struct{}
+
+// FromContext returns the current span, or nil.
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// LogAttrs returns the trace and span IDs of the span in ctx, for log
+// lines, or nothing outside a span.
+func LogAttrs(ctx context.Context) []slog.Attr {
+	sc := FromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("traceId", sc.TraceID.String()),
+		slog.String("spanId", sc.SpanID.String()),
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,81 @@
+package trace
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	tests := map[string]string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+		// Flags other than sampled are not kept.
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		// A later version is read as far as version 00 goes.
+		"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-holds": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	for in, want := range tests {
+		sc, err := ParseTraceparent(in)
+		if err != nil {
+			t.Errorf("ParseTraceparent(%q): %v", in, err)
+			continue
+		}
+		if got := sc.Traceparent(); got != want {
+			t.Errorf("ParseTraceparent(%q).Traceparent() = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseTraceparentRejects(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+		"0-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		if sc, err := ParseTraceparent(in); err == nil {
+			t.Errorf("ParseTraceparent(%q) = %v, want an error", in, sc)
+		}
+	}
+}
+
+func TestStartContinuesTrace(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := NewTracer("test", exporter)
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	remote.TraceState = "vendor=1"
+
+	ctx, parent := tracer.Start(context.Background(), "parent", KindServer, remote)
+	_, child := tracer.Start(ctx, "child", KindInternal, SpanContext{})
+	child.End()
+	parent.End()
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if p.Parent != remote.SpanID || c.Parent != p.SpanContext.SpanID {
+		t.Errorf("parents = %v and %v, want %v and %v", p.Parent, c.Parent, remote.SpanID, p.SpanContext.SpanID)
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID != remote.TraceID || s.SpanContext.TraceState != "vendor=1" {
+			t.Errorf("%v span left the remote trace: %+v", s.Name, s.SpanContext)
+		}
+	}
+
+	// An unsampled trace is propagated but not exported.
+	exporter.Reset()
+	remote.Sampled = false
+	_, span := tracer.Start(context.Background(), "unsampled", KindServer, remote)
+	span.End()
+	if len(exporter.Spans()) != 0 || span.SpanContext().Sampled {
+		t.Error("unsampled span was exported")
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"crypto/tls"
 	"fmt"
+	"log"
 	"net"
 	"net/http"
 )
@@ -25,10 +26,17 @@ func main() {
 
 	config.Certificates = make([]tls.Certificate, len(certs))
 	for i, v := range certs {
-		config.Certificates[i], _ = tls.LoadX509KeyPair(v.CertFile, v.KeyFile)
+		cert, err := tls.LoadX509KeyPair(v.CertFile, v.KeyFile)
+		if err != nil {
+			log.Fatalf("loading %v: %v", v.CertFile, err)
+		}
+		config.Certificates[i] = cert
+	}
+	conn, err := net.Listen("tcp", ":8080")
+	if err != nil {
+		log.Fatal(err)
 	}
-	conn, _ := net.Listen("tcp", ":8080")
 	tlsListener := tls.NewListener(conn, config)
-	httpsServer.Serve(tlsListener)
 	fmt.Println("Listening on port 8080...")
+	log.Fatal(httpsServer.Serve(tlsListener))
 }